@@ -0,0 +1,65 @@
+package assert
+
+import (
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/LaBatata101/goinsta/internal/config"
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/LaBatata101/goinsta/internal/ui"
+)
+
+var projectConfig struct {
+	once sync.Once
+	cfg  config.Config
+	err  error
+}
+
+// Returns the project configuration (`goinsta.yaml` or `.goinsta.toml`) of the module under test. It is
+// loaded once, from the package directory `go test` runs the tests in.
+func getProjectConfig() (config.Config, error) {
+	projectConfig.once.Do(func() {
+		cfg, err := config.Load(".")
+		if err == nil {
+			err = ui.Configure(cfg.Color, cfg.Theme)
+		}
+		if err == nil && cfg.ContextLines != nil {
+			snapshot.DiffContextLines = *cfg.ContextLines
+		}
+		projectConfig.cfg, projectConfig.err = cfg, err
+	})
+	return projectConfig.cfg, projectConfig.err
+}
+
+var workspace struct {
+	once      sync.Once
+	workspace config.Workspace
+	err       error
+}
+
+// Returns the directory of the package of the test function `callerFuncName`, like
+// `example.com/mod/pkg.TestFoo`, when the workspace root is set with `GOINSTA_WORKSPACE_ROOT`, so test
+// binaries built with `go test -c` find their snapshots wherever they run. Otherwise returns an empty
+// string: snapshot directories are relative to the directory the tests run in, which `go test` sets to
+// the package directory.
+func packageDir(callerFuncName string) (string, error) {
+	root := os.Getenv(config.WorkspaceRootEnv)
+	if root == "" {
+		return "", nil
+	}
+
+	workspace.once.Do(func() {
+		workspace.workspace, workspace.err = config.LoadWorkspace(root)
+	})
+	if workspace.err != nil {
+		return "", workspace.err
+	}
+
+	pkgName, _, _ := strings.Cut(path.Base(callerFuncName), ".")
+	// External test packages (`pkg_test`) live in the directory of the package they test.
+	importPath := path.Join(path.Dir(callerFuncName), strings.TrimSuffix(pkgName, "_test"))
+	dir, _ := workspace.workspace.PackageDir(importPath)
+	return dir, nil
+}
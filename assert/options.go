@@ -0,0 +1,258 @@
+package assert
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/LaBatata101/goinsta/internal/redaction"
+)
+
+// Settings control how snapshots are serialized, named and stored. They are built from the project
+// configuration file, the package defaults (`SetDefaults`), the scoped settings of the running test
+// (`WithSettings`) and the options given to the assertion itself, in that order.
+type Settings struct {
+	// Directory where the snapshot files are stored, relative to the package directory.
+	// Defaults to `testdata/snapshots`.
+	SnapshotDir string
+	// Replaces the package name at the start of the snapshot name.
+	SnapshotPrefix string
+	// Appended to the snapshot name as `name@suffix`.
+	SnapshotSuffix string
+	// Serializer used to turn values into snapshots. Defaults to `Debug`.
+	Serializer Serializer
+	Redactions []Redaction
+	Filters    []Filter
+	// Free-form description recorded in the snapshot header.
+	Description string
+	// Don't write `.snap.new` and `.pending-snap` files: mismatches only fail the test with the diff,
+	// like the `no` update mode.
+	NoPendingFiles bool
+
+	// Update mode of the project configuration, overridden by `GOINSTA_UPDATE`.
+	update string
+}
+
+// Replaces the values selected by `Selector` with `Replacement`, see `Redact`.
+type Redaction struct {
+	Selector    string
+	Replacement string
+}
+
+// Replaces the matches of `Pattern` with `Replacement`, see `WithFilter`.
+type Filter struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Option configures a snapshot assertion.
+type Option func(*Settings)
+
+var defaults struct {
+	sync.RWMutex
+	opts []Option
+}
+
+// Sets the options applied to every snapshot assertion of the package, before the options given to
+// the assertion itself. It is meant to be called once, e.g. from `TestMain`:
+//
+//	func TestMain(m *testing.M) {
+//		assert.SetDefaults(assert.WithFilter(regexp.MustCompile(`/tmp/[^/\s]+`), "[tmpdir]"))
+//		os.Exit(m.Run())
+//	}
+func SetDefaults(opts ...Option) {
+	defaults.Lock()
+	defer defaults.Unlock()
+	defaults.opts = append([]Option(nil), opts...)
+}
+
+type settingsScope struct {
+	testName string
+	settings Settings
+}
+
+var scopes struct {
+	sync.Mutex
+	stack []*settingsScope
+}
+
+// Applies `settings` to every snapshot assertion of test `t`, and of its subtests, made while `f`
+// runs. Scopes can be nested, the settings of inner scopes override those of outer ones. Subtests
+// calling `t.Parallel()` only run after `f` returns, so they don't see the scoped settings.
+//
+//	assert.WithSettings(t, assert.Settings{Serializer: assert.JSON, SnapshotSuffix: "v2"}, func() {
+//		assert.Snapshot(t, response)
+//	})
+func WithSettings(t testing.TB, settings Settings, f func()) {
+	t.Helper()
+	scope := &settingsScope{testName: t.Name(), settings: settings}
+
+	scopes.Lock()
+	scopes.stack = append(scopes.stack, scope)
+	scopes.Unlock()
+
+	defer func() {
+		scopes.Lock()
+		defer scopes.Unlock()
+		for i, s := range scopes.stack {
+			if s == scope {
+				scopes.stack = append(scopes.stack[:i], scopes.stack[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	f()
+}
+
+// Returns the settings of an assertion made by test `t` with options `opts`.
+func resolveSettings(t testing.TB, opts []Option) Settings {
+	t.Helper()
+	cfg, err := getProjectConfig()
+	if err != nil {
+		t.Fatal("An error ocurred while loading the goinsta configuration: ", err)
+	}
+
+	s := Settings{SnapshotDir: cfg.SnapshotDir, update: cfg.Update}
+	for _, filter := range cfg.Filters {
+		s.Filters = append(s.Filters, Filter{Pattern: filter.Regexp(), Replacement: filter.Replacement})
+	}
+
+	defaults.RLock()
+	for _, opt := range defaults.opts {
+		opt(&s)
+	}
+	defaults.RUnlock()
+
+	scopes.Lock()
+	name := t.Name()
+	for _, scope := range scopes.stack {
+		if name == scope.testName || strings.HasPrefix(name, scope.testName+"/") {
+			s.merge(scope.settings)
+		}
+	}
+	scopes.Unlock()
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Overrides the fields set in `other`, redactions and filters are added to the existing ones.
+func (s *Settings) merge(other Settings) {
+	if other.SnapshotDir != "" {
+		s.SnapshotDir = other.SnapshotDir
+	}
+	if other.SnapshotPrefix != "" {
+		s.SnapshotPrefix = other.SnapshotPrefix
+	}
+	if other.SnapshotSuffix != "" {
+		s.SnapshotSuffix = other.SnapshotSuffix
+	}
+	if other.Serializer.serialize != nil {
+		s.Serializer = other.Serializer
+	}
+	if other.Description != "" {
+		s.Description = other.Description
+	}
+	if other.NoPendingFiles {
+		s.NoPendingFiles = true
+	}
+	s.Redactions = append(s.Redactions, other.Redactions...)
+	s.Filters = append(s.Filters, other.Filters...)
+}
+
+// Replaces every value selected by `selector` with `replacement` in the snapshot, so fields holding
+// timestamps, UUIDs and such don't change the snapshot on every run.
+//
+// Selectors address struct fields and map keys with `.Name` (or `["key"]`), slice and array elements
+// with `[N]`, and any element, field or key with `[]` or `.*`. For example:
+//
+//	assert.Snapshot(t, v, assert.Redact(".CreatedAt", "[timestamp]"), assert.Redact(".Items[].ID", "[uuid]"))
+func Redact(selector, replacement string) Option {
+	return func(s *Settings) {
+		s.Redactions = append(s.Redactions, Redaction{Selector: selector, Replacement: replacement})
+	}
+}
+
+// Replaces every match of `pattern` in the serialized snapshot with `replacement`, which can reference
+// submatches like `regexp.Regexp.ReplaceAllString` does. Filters run before the snapshot is compared and
+// stored, in the order they were given, and are meant for values that only become non-deterministic
+// once formatted, like temporary paths or addresses inside error messages.
+func WithFilter(pattern *regexp.Regexp, replacement string) Option {
+	return func(s *Settings) {
+		s.Filters = append(s.Filters, Filter{Pattern: pattern, Replacement: replacement})
+	}
+}
+
+// Stores the snapshot files in `dir`, relative to the package directory, instead of `testdata/snapshots`.
+func WithSnapshotDir(dir string) Option {
+	return func(s *Settings) {
+		s.SnapshotDir = dir
+	}
+}
+
+// Replaces the package name at the start of the snapshot name with `prefix`.
+func WithPrefix(prefix string) Option {
+	return func(s *Settings) {
+		s.SnapshotPrefix = prefix
+	}
+}
+
+// Appends `suffix` to the snapshot name, as `name@suffix`.
+func WithSuffix(suffix string) Option {
+	return func(s *Settings) {
+		s.SnapshotSuffix = suffix
+	}
+}
+
+// Records `description` in the snapshot header, to give reviewers some context about the snapshot.
+func WithDescription(description string) Option {
+	return func(s *Settings) {
+		s.Description = description
+	}
+}
+
+// Disables writing `.snap.new` and `.pending-snap` files: mismatches only fail the test with the diff.
+func WithoutPendingFiles() Option {
+	return func(s *Settings) {
+		s.NoPendingFiles = true
+	}
+}
+
+func (s Settings) snapshotDir() string {
+	if s.SnapshotDir == "" {
+		return defaultSnapshotDir
+	}
+	return s.SnapshotDir
+}
+
+func (s Settings) serializer() Serializer {
+	if s.Serializer.serialize == nil {
+		return Debug
+	}
+	return s.Serializer
+}
+
+// Serializes `value` into the snapshot content.
+func (s Settings) serialize(value any) (string, error) {
+	var redactions redaction.Redactions
+	for _, r := range s.Redactions {
+		selector, err := redaction.Parse(r.Selector)
+		if err != nil {
+			return "", err
+		}
+		redactions = append(redactions, redaction.Redaction{Selector: selector, Replacement: r.Replacement})
+	}
+
+	content, err := s.serializer().serialize(value, redactions)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range s.Filters {
+		content = f.Pattern.ReplaceAllString(content, f.Replacement)
+	}
+	return content, nil
+}
@@ -0,0 +1,143 @@
+package assert
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSettingsSerializeRedactions(t *testing.T) {
+	type item struct {
+		ID   string
+		Name string
+	}
+	value := struct {
+		Items []item
+		Meta  map[string]string
+	}{
+		Items: []item{{ID: "1", Name: "apple"}, {ID: "2", Name: "pear"}},
+		Meta:  map[string]string{"request_id": "req-1", "region": "eu"},
+	}
+
+	tests := []struct {
+		name       string
+		redactions []Redaction
+		want       string
+	}{
+		{
+			name:       "field of every element",
+			redactions: []Redaction{{Selector: ".Items[].ID", Replacement: "[id]"}},
+			want:       `{"Items":[{"ID":"[id]","Name":"apple"},{"ID":"[id]","Name":"pear"}],"Meta":{"region":"eu","request_id":"req-1"}}`,
+		},
+		{
+			name:       "single element",
+			redactions: []Redaction{{Selector: ".Items[1].Name", Replacement: "[name]"}},
+			want:       `{"Items":[{"ID":"1","Name":"apple"},{"ID":"2","Name":"[name]"}],"Meta":{"region":"eu","request_id":"req-1"}}`,
+		},
+		{
+			name:       "map key",
+			redactions: []Redaction{{Selector: `.Meta["request_id"]`, Replacement: "[request_id]"}},
+			want:       `{"Items":[{"ID":"1","Name":"apple"},{"ID":"2","Name":"pear"}],"Meta":{"region":"eu","request_id":"[request_id]"}}`,
+		},
+		{
+			name: "last redaction wins",
+			redactions: []Redaction{
+				{Selector: ".Meta.*", Replacement: "[meta]"},
+				{Selector: ".Meta.region", Replacement: "[region]"},
+			},
+			want: `{"Items":[{"ID":"1","Name":"apple"},{"ID":"2","Name":"pear"}],"Meta":{"region":"[region]","request_id":"[meta]"}}`,
+		},
+		{
+			name:       "no match",
+			redactions: []Redaction{{Selector: ".Missing", Replacement: "[missing]"}},
+			want:       `{"Items":[{"ID":"1","Name":"apple"},{"ID":"2","Name":"pear"}],"Meta":{"region":"eu","request_id":"req-1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Settings{Serializer: JSON, Redactions: tt.redactions}
+			got, err := settings.serialize(value)
+			if err != nil {
+				t.Fatalf("serialize() error = %v", err)
+			}
+			if compact := regexp.MustCompile(`\s+`).ReplaceAllString(got, ""); compact != tt.want {
+				t.Errorf("serialize() = %s, want %s", compact, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsSerializeInvalidSelector(t *testing.T) {
+	for _, selector := range []string{"Items", ".Items[", `.Meta["key`, ".Items[x]"} {
+		settings := Settings{Redactions: []Redaction{{Selector: selector, Replacement: "[x]"}}}
+		if _, err := settings.serialize(1); err == nil {
+			t.Errorf("serialize() with selector %q: expected an error", selector)
+		}
+	}
+}
+
+func TestSettingsSerializeFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		filters []Filter
+		want    string
+	}{
+		{
+			name:    "every match",
+			value:   "took 12ms then 3ms",
+			filters: []Filter{{Pattern: regexp.MustCompile(`\d+ms`), Replacement: "[duration]"}},
+			want:    "took [duration] then [duration]",
+		},
+		{
+			name:    "capture groups",
+			value:   "user=alice id=42",
+			filters: []Filter{{Pattern: regexp.MustCompile(`id=(\d+)`), Replacement: "id=[${1}]"}},
+			want:    "user=alice id=[42]",
+		},
+		{
+			name:  "in order",
+			value: "/tmp/abc/config.json",
+			filters: []Filter{
+				{Pattern: regexp.MustCompile(`/tmp/[^/]+`), Replacement: "[tmpdir]"},
+				{Pattern: regexp.MustCompile(`\[tmpdir\]`), Replacement: "$$TMPDIR"},
+			},
+			want: "$TMPDIR/config.json",
+		},
+		{
+			name:    "no match",
+			value:   "unchanged",
+			filters: []Filter{{Pattern: regexp.MustCompile(`\d+`), Replacement: "[n]"}},
+			want:    "unchanged",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Settings{Serializer: Display, Filters: tt.filters}
+			got, err := settings.serialize(tt.value)
+			if err != nil {
+				t.Fatalf("serialize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("serialize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsSerializeFiltersAfterRedactions(t *testing.T) {
+	settings := Settings{
+		Redactions: []Redaction{{Selector: ".ID", Replacement: "[id-1234]"}},
+		Filters:    []Filter{{Pattern: regexp.MustCompile(`\d+`), Replacement: "N"}},
+	}
+	got, err := settings.serialize(struct{ ID, Name string }{"abc", "item 7"})
+	if err != nil {
+		t.Fatalf("serialize() error = %v", err)
+	}
+	want := `struct { ID string; Name string }{
+  ID: "[id-N]",
+  Name: "item N",
+}`
+	if got != want {
+		t.Errorf("serialize() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package assert
+
+import (
+	"fmt"
+
+	"github.com/LaBatata101/goinsta/internal/litter"
+	"github.com/LaBatata101/goinsta/internal/redaction"
+	"github.com/LaBatata101/goinsta/internal/serialize"
+)
+
+// Serializer turns the asserted value into the content of a snapshot. Its name is recorded in the
+// snapshot header, so the `goinsta` CLI can show which format a snapshot uses.
+type Serializer struct {
+	name      string
+	serialize func(value any, redactions redaction.Redactions) (string, error)
+}
+
+func (s Serializer) String() string {
+	return s.name
+}
+
+var (
+	// Go-like representation of the value, e.g. `pkg.T{Field: "value"}`. This is the default serializer.
+	Debug = Serializer{name: "debug", serialize: func(value any, redactions redaction.Redactions) (string, error) {
+		return litter.Options{Redactions: redactions}.Sdump(value), nil
+	}}
+	// Display representation of the value, stored verbatim over multiple lines: strings as they are,
+	// `fmt.Stringer`s and errors through their `String` and `Error` methods, anything else through
+	// `fmt.Sprint`. Redactions don't apply to this serializer, use filters instead.
+	Display = Serializer{name: "display", serialize: func(value any, _ redaction.Redactions) (string, error) {
+		return display(value), nil
+	}}
+	// Indented JSON, using the value's `json` struct tags and sorted object keys.
+	JSON = Serializer{name: "json", serialize: serialize.JSON}
+	// YAML, using the value's `json` struct tags and sorted mapping keys.
+	YAML = Serializer{name: "yaml", serialize: serialize.YAML}
+	// TOML, using the value's `json` struct tags and sorted keys. The value must be a struct or a map.
+	TOML = Serializer{name: "toml", serialize: serialize.TOML}
+	// CSV. The value must be a slice of rows (slices of scalars) or of records (structs or maps), records
+	// get a header row made of their sorted keys.
+	CSV = Serializer{name: "csv", serialize: serialize.CSV}
+)
+
+// Stores the snapshot using `serializer` instead of `Debug`.
+func WithSerializer(serializer Serializer) Option {
+	return func(s *Settings) {
+		s.Serializer = serializer
+	}
+}
+
+func display(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case fmt.Stringer:
+		return value.String()
+	case error:
+		return value.Error()
+	default:
+		return fmt.Sprint(value)
+	}
+}
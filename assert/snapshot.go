@@ -6,77 +6,404 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/LaBatata101/goinsta/internal/gotextdiff"
-	"github.com/LaBatata101/goinsta/internal/litter"
 	"github.com/LaBatata101/goinsta/internal/snapshot"
 	"github.com/LaBatata101/goinsta/internal/ui"
 )
 
-const snapshotDirPath = "testdata/snapshots"
+const defaultSnapshotDir = "testdata/snapshots"
 
+// Import path of this package, used to skip its frames when looking for the caller.
+var assertPkgPath = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	// The name of this function literal is like `example.com/goinsta/assert.init.func1`.
+	return funcPackage(runtime.FuncForPC(pc).Name())
+}()
+
+// Returns the import path of the package of the function named `function`, like `example.com/mod/pkg`
+// for `example.com/mod/pkg.TestFoo.func1`.
+func funcPackage(function string) string {
+	// The type arguments of generic functions may contain slashes.
+	function, _, _ = strings.Cut(function, "[")
+	pkgEnd := strings.LastIndex(function, "/") + 1
+	if dot := strings.Index(function[pkgEnd:], "."); dot != -1 {
+		return function[:pkgEnd+dot]
+	}
+	return function
+}
+
+// Returns the function name, source file and line of the test that called into this package.
+//
+// The test is the outermost frame below the `testing` package runner that belongs to the package of the
+// innermost caller or to a `_test.go` file, so every helper sitting between the test and this package is
+// skipped, whether or not it is marked with `t.Helper()`: the reported location is where the test calls
+// its outermost helper. Frames of other packages, like those of `net/http` running the handler of an
+// `httptest` server on its own goroutine, are never reported.
+func getTestCaller() (string, string, int) {
+	return findCaller(true)
+}
+
+// Returns the function name, source file and line of the function that called into this package.
 func getParentCallerFuncName() (string, string, int) {
-	pc, sourceFile, loc, ok := runtime.Caller(2)
-	if !ok {
+	return findCaller(false)
+}
+
+// Walks the stack up to the frames of the test runner and returns the innermost frame outside of this
+// package, or the outermost one of its package or of a `_test.go` file when `outermost` is set. The
+// frames of the tests of this package count as callers. `t.Helper()` marks are kept private by the
+// `testing` package, so they aren't looked at.
+func findCaller(outermost bool) (string, string, int) {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var caller runtime.Frame
+	var callerPkg string
+	for {
+		frame, more := frames.Next()
+		if isRunnerFrame(frame.Function) {
+			break
+		}
+
+		pkg, testFile := funcPackage(frame.Function), strings.HasSuffix(frame.File, "_test.go")
+		switch {
+		case pkg == assertPkgPath && !testFile:
+		case caller.Function == "":
+			caller, callerPkg = frame, pkg
+			if !outermost {
+				return caller.Function, caller.File, caller.Line
+			}
+		case pkg == callerPkg || testFile:
+			caller = frame
+		}
+		if !more {
+			break
+		}
+	}
+
+	if caller.Function == "" {
 		log.Fatal("Failed to get parent caller function name")
 	}
+	return caller.Function, caller.File, caller.Line
+}
 
-	fn := runtime.FuncForPC(pc)
-	return fn.Name(), sourceFile, loc
+// Reports whether `function` belongs to the machinery that runs tests, benchmarks and fuzz targets.
+func isRunnerFrame(function string) bool {
+	return strings.HasPrefix(function, "testing.") || strings.HasPrefix(function, "runtime.") ||
+		strings.HasPrefix(function, "reflect.")
 }
 
-func Snapshot(t *testing.T, value any) {
-	if _, err := os.Stat(snapshotDirPath); errors.Is(err, fs.ErrNotExist) {
-		err := os.MkdirAll(snapshotDirPath, 0755)
-		if err != nil {
-			t.Fatal("An error ocurred while creating the snapshot directory: ", err)
-		}
+// Asserts that `value` matches the snapshot stored in `testdata/snapshots`. The snapshot is named after
+// the running test (`t.Name()`), so every subtest gets a snapshot of its own. Repeated calls within the
+// same test get a `~2`, `~3`, ... suffix.
+//
+// `t` can be a test, a benchmark, a fuzz target or any helper type implementing `testing.TB`.
+func Snapshot(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but appends `+name` to the snapshot name, so a test can keep several snapshots apart.
+func SnapshotNamed(t testing.TB, name string, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	assertSnapshot(t, callerFuncName, name, value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but stores the display representation of the value (see `Display`), so strings and
+// `fmt.Stringer` output are stored verbatim instead of as a quoted Go string. Leading and trailing
+// blank lines are not kept.
+func SnapshotString(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	opts = append([]Option{WithSerializer(Display)}, opts...)
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but stores the value as indented JSON.
+func SnapshotJSON(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	opts = append([]Option{WithSerializer(JSON)}, opts...)
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but stores the value as YAML.
+func SnapshotYAML(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	opts = append([]Option{WithSerializer(YAML)}, opts...)
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but stores the value as TOML. The value must be a struct or a map.
+func SnapshotTOML(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	opts = append([]Option{WithSerializer(TOML)}, opts...)
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Like `Snapshot`, but stores the value as CSV. The value must be a slice of rows, structs or maps.
+func SnapshotCSV(t testing.TB, value any, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getTestCaller()
+	opts = append([]Option{WithSerializer(CSV)}, opts...)
+	assertSnapshot(t, callerFuncName, "", value, sourceFile, loc, opts)
+}
+
+// Returns the snapshot name of test `t`, prefixed by the package of the caller function (or by
+// `SnapshotPrefix`) and followed by `@SnapshotSuffix`, if any. The subtest `TestFoo/case_name` of
+// package `pkg` is named `pkg.TestFoo.case_name`, see `sanitizeSegment`.
+func testSnapshotName(t testing.TB, callerFuncName string, s Settings) string {
+	prefix, _, _ := strings.Cut(filepath.Base(callerFuncName), ".")
+	if s.SnapshotPrefix != "" {
+		prefix = sanitizeName(s.SnapshotPrefix)
 	}
 
-	callerFuncName, sourceFile, loc := getParentCallerFuncName()
-	callerFuncName = filepath.Base(callerFuncName)
-	snapshotName := strings.ReplaceAll(callerFuncName, ".", "__") + ".snap"
-	snapshotPath := filepath.Join(snapshotDirPath, snapshotName)
+	segments := strings.Split(t.Name(), "/")
+	for i, segment := range segments {
+		segments[i] = sanitizeSegment(segment)
+	}
+
+	name := prefix + "." + strings.Join(segments, ".")
+	if s.SnapshotSuffix != "" {
+		name += "@" + sanitizeName(s.SnapshotSuffix)
+	}
+	return name
+}
+
+var snapshotCounters = struct {
+	sync.Mutex
+	m map[testing.TB]map[string]int
+}{m: make(map[testing.TB]map[string]int)}
+
+// Returns `name` the first time it is asserted in test `t`, and `name~2`, `name~3`, ... afterwards. The
+// separator is a character `sanitizeName` never keeps, so the counter can't be confused with the end of a
+// test name like `case-2`.
+func nextSnapshotName(t testing.TB, name string) string {
+	snapshotCounters.Lock()
+	defer snapshotCounters.Unlock()
+
+	counts, ok := snapshotCounters.m[t]
+	if !ok {
+		counts = make(map[string]int)
+		snapshotCounters.m[t] = counts
+		t.Cleanup(func() {
+			snapshotCounters.Lock()
+			delete(snapshotCounters.m, t)
+			snapshotCounters.Unlock()
+		})
+	}
+
+	counts[name]++
+	if n := counts[name]; n > 1 {
+		return name + "~" + strconv.Itoa(n)
+	}
+	return name
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_.#@-]+`)
+
+// Replaces the characters that aren't safe to use in a file name.
+func sanitizeName(name string) string {
+	return unsafeNameChars.ReplaceAllString(name, "_")
+}
+
+// Like `sanitizeName`, but also replaces the separators of the snapshot names: `.` between the subtest
+// segments and `@` before the suffix, so `TestFoo/a.b` and `TestFoo/a/b` get snapshots of their own.
+func sanitizeSegment(segment string) string {
+	return strings.NewReplacer(".", "_", "@", "_").Replace(sanitizeName(segment))
+}
+
+func assertSnapshot(t testing.TB, callerFuncName, name string, value any, sourceFile string, loc int, opts []Option) {
+	t.Helper()
+	settings := resolveSettings(t, opts)
+
+	testName := testSnapshotName(t, callerFuncName, settings)
+	if name != "" {
+		// `+` never appears in the sanitized test name, unlike the `.` separating the subtests.
+		testName += "+" + sanitizeName(name)
+	}
+	snapshotName := nextSnapshotName(t, testName)
+	pkgDir, err := packageDir(callerFuncName)
+	if err != nil {
+		t.Fatal("An error ocurred while loading the goinsta workspace: ", err)
+	}
+	snapshotDir := settings.snapshotDir()
+	if pkgDir != "" && !filepath.IsAbs(snapshotDir) {
+		snapshotDir = filepath.Join(pkgDir, snapshotDir)
+	}
+	snapshotPath := filepath.Join(snapshotDir, strings.ReplaceAll(snapshotName, ".", "__")+".snap")
 	snapshotFullPath, err := filepath.Abs(snapshotPath)
 	if err != nil {
 		t.Fatal("An error ocurred while creating absulute path for snapshot file: ", err)
 	}
 
-	newContent := litter.Sdump(value) + "\n"
+	newContent, err := settings.serialize(value)
+	if err != nil {
+		t.Fatal("An error ocurred while serializing the snapshot: ", err)
+	}
+	// Snapshot files don't keep the blank lines around the content.
+	newContent = strings.Trim(newContent, "\n") + "\n"
+	newSnap := snapshot.Snapshot{
+		Name:        snapshotName,
+		Source:      sourceFile,
+		Loc:         loc,
+		Content:     newContent,
+		Serializer:  settings.serializer().name,
+		Description: settings.Description,
+	}
+
+	mode, err := getUpdateMode(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode != updateNo {
+		if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+			t.Fatal("An error ocurred while creating the snapshot directory: ", err)
+		}
+	}
 
+	// Without a snapshot directory there is nothing `goinsta clean` could find orphaned in it anyway.
+	if err := snapshot.RecordReference(snapshotFullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Log("Failed to record the snapshot in the run manifest: ", err)
+	}
+
+	var oldContent string
 	_, err = os.Stat(snapshotPath)
-	if err == nil {
-		// Don't need to handle error here, since, we already checkd that `snapshotPath` exist.
-		snap, _ := snapshot.Read(snapshotPath)
-		edits := gotextdiff.Strings(newContent, snap.Content+"\n")
-
-		// Only show summary if the snapshot content was changed
-		if len(edits) > 0 {
-			snap, err := snapshot.Write(snapshotFullPath, callerFuncName, newContent, sourceFile, loc)
-			if err != nil {
-				t.Fatal("An error ocurred while creating new snapshot file: ", err)
-			}
+	exists := err == nil
+	if exists {
+		snap, err := snapshot.Read(snapshotPath)
+		if err != nil {
+			t.Fatal("An error ocurred while reading snapshot file: ", err)
+		}
 
-			ui.RenderSnapshotSummary(&snap)
-			t.Fail()
+		oldContent = snap.Content + "\n"
+		if edits := gotextdiff.Strings(newContent, oldContent); len(edits) == 0 {
+			return
 		}
-	} else if errors.Is(err, fs.ErrNotExist) {
-		snap, err := snapshot.Write(snapshotFullPath, callerFuncName, newContent, sourceFile, loc)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal("An error ocurred while checking snapshot file: ", err)
+	}
+
+	infoLog := log.New(os.Stdout, ui.BoldText.Render("INFO: "), 0)
+	switch {
+	case mode == updateNo && !exists:
+		t.Errorf("missing snapshot %s (%s), review it with `goinsta review` outside of CI:\n%s",
+			snapshotName, snapshotFullPath, plainDiff(oldContent, newContent))
+	case mode == updateNo:
+		t.Errorf("snapshot %s doesn't match %s:\n%s", snapshotName, snapshotFullPath, plainDiff(oldContent, newContent))
+	case mode == updateAlways || (mode == updateUnseen && !exists):
+		snap, err := snapshot.Write(snapshotFullPath, newSnap)
+		if err != nil {
+			t.Fatal("An error ocurred while creating new snapshot file: ", err)
+		}
+		if err := snap.Accept(); err != nil {
+			t.Fatal("An error ocurred while updating snapshot file: ", err)
+		}
+
+		infoLog.Printf("%s %s", ui.GreenText.Render("updated snapshot"),
+			ui.GreenText2Underlined.Render(snapshotFullPath))
+	default:
+		snap, err := snapshot.Write(snapshotFullPath, newSnap)
 		if err != nil {
 			t.Fatal("An error ocurred while creating new snapshot file: ", err)
 		}
 
-		// TODO: keep this as a log?
-		infoLog := log.New(os.Stdout, ui.BoldText.Render("INFO: "), 0)
-		infoLog.Printf("%s %s", ui.GreenText.Render("stored new snapshot"),
-			ui.GreenText2Underlined.Render(snapshotFullPath+".new"))
+		if !exists {
+			// TODO: keep this as a log?
+			infoLog.Printf("%s %s", ui.GreenText.Render("stored new snapshot"),
+				ui.GreenText2Underlined.Render(snapshotFullPath+".new"))
+		}
+
+		ui.RenderSnapshotSummary(&snap)
+		t.Fail()
+	}
+}
+
+// Returns the unified diff between the `old` and `new` snapshot contents, for test failure messages.
+func plainDiff(old, new string) string {
+	var lines []string
+	for _, line := range strings.Split(gotextdiff.Unified(old, new), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Asserts that `value` matches the `expected` snapshot, which is stored as a string literal in the test
+// source itself. On mismatch the new value is recorded in a `.pending-snap` file next to the test, and
+// accepting it with `goinsta accept` or `goinsta review` rewrites the literal in place. `expected` must
+// be a string literal written in the call, the test fails otherwise.
+func InlineSnapshot(t testing.TB, value any, expected string, opts ...Option) {
+	t.Helper()
+	callerFuncName, sourceFile, loc := getParentCallerFuncName()
+	settings := resolveSettings(t, opts)
+	snapshotName := testSnapshotName(t, callerFuncName, settings)
+
+	newContent, err := settings.serialize(value)
+	if err != nil {
+		t.Fatal("An error ocurred while serializing the snapshot: ", err)
+	}
+	newContent = snapshot.NormalizeInline(newContent)
+
+	mode, err := getUpdateMode(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldContent := snapshot.NormalizeInline(expected)
+	edits := gotextdiff.Strings(oldContent, newContent)
+	if len(edits) == 0 {
+		// The literal matches again, drop any value left pending by a previous run.
+		os.Remove(snapshot.InlinePendingPath(sourceFile, currentInlineLine(sourceFile, loc)))
+		return
+	}
+
+	switch {
+	case mode == updateNo && oldContent == "":
+		t.Errorf("missing inline snapshot %s at %s:%d:\n%s", snapshotName, sourceFile, loc, plainDiff(oldContent, newContent))
+	case mode == updateNo:
+		t.Errorf("inline snapshot %s at %s:%d doesn't match:\n%s",
+			snapshotName, sourceFile, loc, plainDiff(oldContent, newContent))
+	case mode == updateAlways || (mode == updateUnseen && oldContent == ""):
+		if err := rewriteInlineSnapshot(sourceFile, loc, newContent); err != nil {
+			t.Fatal("An error ocurred while updating inline snapshot: ", err)
+		}
+		os.Remove(snapshot.InlinePendingPath(sourceFile, currentInlineLine(sourceFile, loc)))
+	default:
+		line := currentInlineLine(sourceFile, loc)
+		// A pending snapshot of a value passed through a helper could only be accepted by rewriting the helper.
+		if _, err := snapshot.ReadInlineLiteral(sourceFile, line); errors.Is(err, snapshot.ErrInlineNotLiteral) {
+			t.Fatalf("inline snapshot %s at %s:%d doesn't match, and can't be updated because its expected value "+
+				"isn't a string literal written in the InlineSnapshot call:\n%s",
+				snapshotName, sourceFile, loc, plainDiff(oldContent, newContent))
+		}
+
+		snap, err := snapshot.WriteInline(snapshot.Snapshot{
+			Name:        snapshotName,
+			Source:      sourceFile,
+			Loc:         line,
+			Content:     newContent,
+			Serializer:  settings.serializer().name,
+			Description: settings.Description,
+		})
+		if err != nil {
+			t.Fatal("An error ocurred while creating pending inline snapshot file: ", err)
+		}
 
 		ui.RenderSnapshotSummary(&snap)
 		t.Fail()
-	} else {
-		t.Fatal("An error ocurred while checking snapshot file: ", err)
 	}
 }
@@ -0,0 +1,45 @@
+package assert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Calls `getTestCaller` through a helper, like the assertions do.
+func testCaller() (string, string, int) {
+	return getTestCaller()
+}
+
+func TestGetTestCaller(t *testing.T) {
+	function, file, _ := testCaller()
+	if function != assertPkgPath+".TestGetTestCaller" || filepath.Base(file) != "snapshot_internal_test.go" {
+		t.Errorf("getTestCaller() = %s, %s, want this test", function, file)
+	}
+}
+
+func TestGetTestCallerInHTTPHandler(t *testing.T) {
+	var function, file string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		function, file, _ = testCaller()
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// The handler runs on a goroutine started by `net/http`, whose frames must not be reported.
+	if !strings.HasPrefix(function, assertPkgPath+".TestGetTestCallerInHTTPHandler.") ||
+		filepath.Base(file) != "snapshot_internal_test.go" {
+		t.Errorf("getTestCaller() = %s, %s, want the handler of this test", function, file)
+	}
+}
+
+func TestSnapshotInInternalTest(t *testing.T) {
+	Snapshot(t, "asserted from the tests of the assert package")
+}
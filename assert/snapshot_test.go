@@ -1,7 +1,14 @@
 package assert_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/LaBatata101/goinsta/assert"
 )
@@ -78,3 +85,206 @@ aliquam ut erat in, iaculis porta diam. Phasellus cursus feugiat ultrices. Suspe
 lectus lobortis sollicitudin nec sed ante. Ut sed velit vehicula, tincidunt eros eu, aliquam dolor. Nulla diam lacus,
 feugiat at turpis in, elementum vestibulum eros.`)
 }
+
+func TestInlineSnapshot(t *testing.T) {
+	assert.InlineSnapshot(t, []string{"Hello", "World", "!"}, `
+[]string{
+  "Hello",
+  "World",
+  "!",
+}`)
+}
+
+func TestInlineSnapshotSingleLine(t *testing.T) {
+	assert.InlineSnapshot(t, 42, `42`)
+}
+
+func TestSnapshotNamed(t *testing.T) {
+	assert.SnapshotNamed(t, "first", []int{1, 2, 3})
+	assert.SnapshotNamed(t, "second", map[string]int{"a": 1})
+}
+
+func TestSnapshotMultipleAssertions(t *testing.T) {
+	assert.Snapshot(t, "first value")
+	assert.Snapshot(t, "second value")
+	assert.Snapshot(t, "third value")
+}
+
+func TestSnapshotSubtests(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"integer", 10},
+		{"string value", "hello"},
+		{"slice", []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Snapshot(t, tt.value)
+		})
+	}
+}
+
+func TestSnapshotRedactions(t *testing.T) {
+	type Item struct {
+		ID   string
+		Name string
+	}
+	type Order struct {
+		CreatedAt time.Time
+		Items     []Item
+		Meta      map[string]string
+	}
+	order := Order{
+		CreatedAt: time.Now(),
+		Items: []Item{
+			{ID: "5f1c8e0a-3c0e-4f4e-9a57-0d3ae2b4c1f1", Name: "apple"},
+			{ID: "a1d3b7c2-7f8e-4b8c-8d2e-6f0a9c1b2e3d", Name: "pear"},
+		},
+		Meta: map[string]string{
+			"request_id": "req-1234",
+			"region":     "eu",
+		},
+	}
+
+	assert.Snapshot(t, order,
+		assert.Redact(".CreatedAt", "[timestamp]"),
+		assert.Redact(".Items[].ID", "[uuid]"),
+		assert.Redact(`.Meta["request_id"]`, "[request_id]"),
+	)
+}
+
+func TestSnapshotFilters(t *testing.T) {
+	start := time.Now()
+	err := fmt.Errorf("open %s: permission denied after %dms", filepath.Join(t.TempDir(), "config.json"),
+		time.Since(start).Milliseconds())
+	assert.Snapshot(t, err.Error(),
+		assert.WithFilter(regexp.MustCompile(`/\S*/config\.json`), "[tmpdir]/config.json"),
+		assert.WithFilter(regexp.MustCompile(`\d+ms`), "[duration]"),
+	)
+}
+
+type User struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Admin   bool              `json:"admin"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Created time.Time         `json:"created"`
+}
+
+var users = []User{
+	{Name: "alice", Age: 30, Admin: true, Tags: []string{"a", "b"}, Labels: map[string]string{"z": "1", "a": "2"}},
+	{Name: "bob", Age: 25, Tags: []string{}, Labels: map[string]string{}},
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	assert.SnapshotJSON(t, users, assert.Redact("[].created", "[timestamp]"))
+}
+
+func TestSnapshotYAML(t *testing.T) {
+	assert.SnapshotYAML(t, users[0], assert.Redact(".created", "[timestamp]"))
+}
+
+func TestSnapshotTOML(t *testing.T) {
+	assert.SnapshotTOML(t, users[0], assert.Redact(".created", "[timestamp]"))
+}
+
+func TestSnapshotCSV(t *testing.T) {
+	assert.SnapshotCSV(t, users, assert.Redact("[].created", "[timestamp]"))
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("v%d.%d", v.major, v.minor)
+}
+
+func TestSnapshotStringDisplay(t *testing.T) {
+	assert.SnapshotString(t, `Usage:
+  goinsta [flags]
+  goinsta [command]
+
+Flags:
+  -h, --help   help for goinsta`)
+}
+
+func TestSnapshotStringStringer(t *testing.T) {
+	assert.SnapshotString(t, version{1, 2})
+}
+
+func snapshotUpper(t testing.TB, s string) {
+	t.Helper()
+	assert.SnapshotString(t, strings.ToUpper(s))
+}
+
+func TestSnapshotFromHelper(t *testing.T) {
+	snapshotUpper(t, "called from a helper")
+}
+
+func FuzzSnapshotSeeds(f *testing.F) {
+	f.Add("hello")
+	f.Add("world")
+	f.Fuzz(func(t *testing.T, s string) {
+		snapshotUpper(t, s)
+	})
+}
+
+func TestSnapshotOptions(t *testing.T) {
+	assert.Snapshot(t, []int{1, 2, 3},
+		assert.WithSuffix("v2"),
+		assert.WithDescription("numbers with a suffix"),
+	)
+}
+
+func TestSnapshotWithSettings(t *testing.T) {
+	settings := assert.Settings{
+		SnapshotDir:    "testdata/snapshots/scoped",
+		SnapshotPrefix: "scoped",
+		Serializer:     assert.JSON,
+		Redactions:     []assert.Redaction{{Selector: ".id", Replacement: "[id]"}},
+	}
+
+	assert.WithSettings(t, settings, func() {
+		assert.Snapshot(t, map[string]any{"id": 1234, "name": "scoped"})
+
+		t.Run("subtest", func(t *testing.T) {
+			assert.Snapshot(t, map[string]any{"id": 5678, "name": "inherited"})
+		})
+	})
+}
+
+func TestSnapshotInHTTPHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Snapshot(t, r.URL.Path)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/users/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestSnapshotSubtestSeparators(t *testing.T) {
+	t.Run("a.b", func(t *testing.T) {
+		assert.Snapshot(t, "subtest a.b")
+	})
+	t.Run("a", func(t *testing.T) {
+		t.Run("b", func(t *testing.T) {
+			assert.Snapshot(t, "subtest b of a")
+		})
+	})
+}
+
+func TestSnapshotNamedAndSubtest(t *testing.T) {
+	assert.SnapshotNamed(t, "x", "named snapshot")
+	t.Run("x", func(t *testing.T) {
+		assert.Snapshot(t, "subtest snapshot")
+	})
+}
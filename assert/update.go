@@ -0,0 +1,117 @@
+package assert
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+)
+
+// How snapshot mismatches are handled, selected with the `GOINSTA_UPDATE` environment variable.
+type updateMode string
+
+const (
+	// Write the mismatching snapshot as a `.snap.new` (or `.pending-snap`) file to review, and fail.
+	// This is the default.
+	updateNew updateMode = "new"
+	// Overwrite the `.snap` file (or the inline literal) directly, and pass.
+	updateAlways updateMode = "always"
+	// Never write any file, only fail with the diff. This is the default in CI.
+	updateNo updateMode = "no"
+	// Write snapshots that don't exist yet directly, but stage the changes to existing ones like `new`.
+	updateUnseen updateMode = "unseen"
+)
+
+const (
+	updateModeEnv = "GOINSTA_UPDATE"
+	ciEnv         = "GOINSTA_CI"
+)
+
+// Returns the update mode selected with `GOINSTA_UPDATE`, or else by the project configuration. In CI,
+// or when `settings` disable pending files, the modes that would leave pending snapshot files in the
+// checkout (`new` and `unseen`) behave like `no`.
+func getUpdateMode(settings Settings) (updateMode, error) {
+	source := updateModeEnv
+	value, ok := os.LookupEnv(updateModeEnv)
+	if !ok || value == "" {
+		source, value = "update mode", settings.update
+	}
+
+	mode := updateMode(strings.ToLower(strings.TrimSpace(value)))
+	switch mode {
+	case "":
+		mode = updateNew
+	case updateNew, updateAlways, updateNo, updateUnseen:
+	default:
+		return "", fmt.Errorf("invalid %s value %q, expected one of: new, always, no, unseen", source, mode)
+	}
+
+	if (isCI() || settings.NoPendingFiles) && mode != updateAlways {
+		return updateNo, nil
+	}
+	return mode, nil
+}
+
+// Reports whether the tests are running in CI, either because `GOINSTA_CI` is on or, when it isn't
+// set, because `CI` is.
+func isCI() bool {
+	if value, ok := os.LookupEnv(ciEnv); ok {
+		return isTruthy(value)
+	}
+	return isTruthy(os.Getenv("CI"))
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+type inlineShift struct {
+	line  int
+	delta int
+}
+
+// Lines added or removed in each source file by the inline snapshots rewritten during this run. The
+// assertion lines reported by the runtime are those of the compiled source, so they have to be moved by
+// the shifts of every literal rewritten above them.
+var inlineShifts = struct {
+	sync.Mutex
+	m map[string][]inlineShift
+}{m: make(map[string][]inlineShift)}
+
+// Returns the line where the assertion compiled at `source:loc` currently is.
+func currentInlineLine(source string, loc int) int {
+	inlineShifts.Lock()
+	defer inlineShifts.Unlock()
+	return shiftedLine(source, loc)
+}
+
+func shiftedLine(source string, loc int) int {
+	line := loc
+	for _, shift := range inlineShifts.m[source] {
+		if shift.line < loc {
+			line += shift.delta
+		}
+	}
+	return line
+}
+
+// Rewrites the literal of the inline snapshot compiled at `source:loc` with `content`.
+func rewriteInlineSnapshot(source string, loc int, content string) error {
+	inlineShifts.Lock()
+	defer inlineShifts.Unlock()
+
+	delta, err := snapshot.RewriteInline(source, shiftedLine(source, loc), content)
+	if err != nil {
+		return err
+	}
+	if delta != 0 {
+		inlineShifts.m[source] = append(inlineShifts.m[source], inlineShift{line: loc, delta: delta})
+	}
+	return nil
+}
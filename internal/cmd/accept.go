@@ -10,27 +10,48 @@ import (
 )
 
 func init() {
+	addFilterFlags(acceptCmd)
+	addFormatFlag(acceptCmd)
 	rootCmd.AddCommand(acceptCmd)
 }
 
 var acceptCmd = &cobra.Command{
-	Use:   "accept",
+	Use:   "accept [paths]",
 	Short: "Accept all snapshots",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFormat()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		snapshots, err := snapshot.GetNewSnapshotPaths()
+		snapshots, err := getPendingSnapshots(args)
 		if err != nil {
 			log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
 		}
 
+		if outputFormat != "text" {
+			outputs, err := snapshotOutputs(snapshots, "accepted")
+			if err != nil {
+				log.Fatal("An error ocurred while reading snapshots: ", err)
+			}
+			if _, err := snapshot.AcceptAll(snapshots); err != nil {
+				log.Fatal("An error ocurred while accepting snapshots: ", err)
+			}
+			if err := printSnapshotOutputs(outputs); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
 		if len(snapshots) == 0 {
 			fmt.Println("no snapshots to review")
 			return
 		}
 
-		acceptedSnaps, err := snapshot.AcceptAll(snapshots)
-		if err != nil {
-			log.Fatal("An error ocurred while accepting snapshots: ", err)
-		}
-		ui.PrintAccepted(acceptedSnaps)
+		forEachModule(snapshots, func(snapshots []string) {
+			acceptedSnaps, err := snapshot.AcceptAll(snapshots)
+			if err != nil {
+				log.Fatal("An error ocurred while accepting snapshots: ", err)
+			}
+			ui.PrintAccepted(acceptedSnaps)
+		})
 	},
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/LaBatata101/goinsta/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	unreferenced string
+	manifestPath string
+	assumeYes    bool
+)
+
+func init() {
+	cleanCmd.Flags().StringVar(&unreferenced, "unreferenced", "warn",
+		"what to do with snapshots no test asserted during the last run: warn, delete or reject")
+	cleanCmd.Flags().StringVar(&manifestPath, "manifest", os.Getenv(snapshot.ManifestEnv),
+		"run manifest shared by every package, instead of the per-directory ones (default $"+snapshot.ManifestEnv+")")
+	cleanCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false,
+		"delete the unreferenced snapshots without asking for confirmation")
+	rootCmd.AddCommand(cleanCmd)
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Find snapshots that are no longer referenced by any test",
+	Long: `Find snapshots that are no longer referenced by any test.
+
+Every snapshot asserted while running the tests is recorded in the run manifest of its snapshot
+directory. Snapshot files missing from the manifest belong to tests that were renamed or deleted.
+Run the whole test suite before cleaning, snapshots of tests that didn't run are reported as well.
+Directories whose last run selected tests with -run or -skip are skipped, and the unreferenced
+snapshots are listed and confirmed before being deleted. Without a terminal, deleting them requires
+--yes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch unreferenced {
+		case "warn", "delete", "reject":
+		default:
+			return fmt.Errorf("invalid --unreferenced value %q, expected one of: warn, delete, reject", unreferenced)
+		}
+
+		report, err := snapshot.FindOrphans(workspace.Dirs(), cfg.Ignore, manifestPath)
+		if err := deferScanError(err); err != nil {
+			log.Fatal("An error ocurred while looking for unreferenced snapshots: ", err)
+		}
+
+		for _, dir := range report.Unchecked {
+			fmt.Printf("%s %s\n", ui.YellowText.Render("no run manifest, skipping"), dir)
+		}
+		for _, path := range report.Partial {
+			fmt.Printf("%s %s\n", ui.YellowText.Render("run manifest of a partial run, skipping"), path)
+		}
+
+		if len(report.Orphans) == 0 {
+			fmt.Println("no unreferenced snapshots")
+			return nil
+		}
+
+		if unreferenced == "delete" {
+			if !assumeYes && !term.IsTerminal(int(os.Stdin.Fd())) {
+				forEachModule(report.Orphans, func(paths []string) {
+					ui.PrintPaths(ui.YellowText.Render("Unreferenced snapshots"), paths)
+				})
+				return fmt.Errorf("refusing to delete %d unreferenced snapshots without --yes, stdin isn't a terminal",
+					len(report.Orphans))
+			}
+			if !assumeYes && !confirmDeletion(report.Orphans) {
+				fmt.Println("no snapshot was deleted")
+				return nil
+			}
+			for _, path := range report.Orphans {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+			forEachModule(report.Orphans, func(paths []string) {
+				ui.PrintPaths(ui.RedText.Render("Deleted unreferenced snapshots"), paths)
+			})
+			return nil
+		}
+
+		forEachModule(report.Orphans, func(paths []string) {
+			ui.PrintPaths(ui.YellowText.Render("Unreferenced snapshots"), paths)
+		})
+		if unreferenced == "reject" {
+			return fmt.Errorf("found %d unreferenced snapshots", len(report.Orphans))
+		}
+		return nil
+	},
+}
+
+// Lists the snapshots at `paths` and asks whether to delete them, reading the answer from stdin.
+func confirmDeletion(paths []string) bool {
+	forEachModule(paths, func(paths []string) {
+		ui.PrintPaths(ui.YellowText.Render("Unreferenced snapshots"), paths)
+	})
+	fmt.Printf("Delete these %d snapshots? [y/N] ", len(paths))
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
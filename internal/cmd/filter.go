@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// Filter of the pending snapshots the command works on.
+var filter snapshot.Filter
+
+// Adds the flags selecting the pending snapshots to `cmd`, which takes the paths of pending snapshots,
+// or of directories containing them, as arguments.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&filter.Packages, "package", nil,
+		"only the snapshots of the packages in these directories, like ./internal/...")
+	cmd.Flags().StringSliceVar(&filter.Tests, "test", nil,
+		"only the snapshots of the tests matching these glob patterns, like 'TestParser*'")
+	cmd.Flags().StringSliceVar(&filter.Names, "name", nil,
+		"only the snapshots whose name matches these glob patterns, like 'pkg.TestParser*'")
+	cmd.Flags().BoolVar(&filter.OnlyNew, "only-new", false, "only the snapshots that don't replace an accepted snapshot")
+	cmd.Flags().BoolVar(&filter.OnlyChanged, "only-changed", false, "only the snapshots that replace an accepted snapshot")
+	cmd.MarkFlagsMutuallyExclusive("only-new", "only-changed")
+}
+
+// Returns the pending snapshots at the `args` paths, or in the whole workspace if there are none,
+// selected by the filter flags.
+func getPendingSnapshots(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = workspace.Dirs()
+	}
+	paths, err := snapshot.GetNewSnapshotPaths(args, cfg.Ignore)
+	if err := deferScanError(err); err != nil {
+		return nil, err
+	}
+	return filter.Select(paths)
+}
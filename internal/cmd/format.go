@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// Output format of the command: `text`, `json` or `ndjson`.
+var outputFormat string
+
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "output format: text, json or ndjson (one JSON object per line)")
+}
+
+func validateFormat() error {
+	switch outputFormat {
+	case "text", "json", "ndjson":
+		return nil
+	default:
+		return fmt.Errorf("invalid --format value %q, expected one of: text, json, ndjson", outputFormat)
+	}
+}
+
+// A pending snapshot in the `json` and `ndjson` output formats.
+type snapshotOutput struct {
+	snapshot.Info
+	// Module path of the snapshot, in a workspace with several modules.
+	Module string `json:"module,omitempty"`
+	// `accepted` or `rejected`, for the commands acting on the snapshots.
+	Action string `json:"action,omitempty"`
+}
+
+// Returns the outputs of the pending snapshots at `paths`, which must be read before acting on them.
+func snapshotOutputs(paths []string, action string) ([]snapshotOutput, error) {
+	outputs := make([]snapshotOutput, 0, len(paths))
+	for _, path := range paths {
+		snap, err := snapshot.Read(path)
+		if err != nil {
+			return nil, err
+		}
+
+		output := snapshotOutput{Info: snap.Info(), Action: action}
+		if len(workspace.Modules) > 1 {
+			if module, ok := workspace.ModuleOf(path); ok {
+				output.Module = module.Path
+			}
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// Prints `outputs` in the `json` or `ndjson` format.
+func printSnapshotOutputs(outputs []snapshotOutput) error {
+	encoder := json.NewEncoder(os.Stdout)
+	if outputFormat == "json" {
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(outputs)
+	}
+
+	for _, output := range outputs {
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
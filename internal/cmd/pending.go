@@ -4,25 +4,42 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/LaBatata101/goinsta/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	addFilterFlags(pendingCmd)
+	addFormatFlag(pendingCmd)
 	rootCmd.AddCommand(pendingCmd)
 }
 
 var pendingCmd = &cobra.Command{
-	Use:   "pending-snapshots",
+	Use:   "pending-snapshots [paths]",
 	Short: "List all pending snapshots",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFormat()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		snapshots, err := snapshot.GetNewSnapshotPaths()
+		snapshots, err := getPendingSnapshots(args)
 		if err != nil {
 			log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
 		}
 
-		for _, snap := range snapshots {
-			fmt.Println(snap)
+		if outputFormat != "text" {
+			outputs, err := snapshotOutputs(snapshots, "")
+			if err != nil {
+				log.Fatal("An error ocurred while reading snapshots: ", err)
+			}
+			if err := printSnapshotOutputs(outputs); err != nil {
+				log.Fatal(err)
+			}
+			return
 		}
+
+		forEachModule(snapshots, func(snapshots []string) {
+			for _, snap := range snapshots {
+				fmt.Println(snap)
+			}
+		})
 	},
 }
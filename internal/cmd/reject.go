@@ -10,27 +10,48 @@ import (
 )
 
 func init() {
+	addFilterFlags(rejectCmd)
+	addFormatFlag(rejectCmd)
 	rootCmd.AddCommand(rejectCmd)
 }
 
 var rejectCmd = &cobra.Command{
-	Use:   "reject",
+	Use:   "reject [paths]",
 	Short: "Reject all snapshots",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateFormat()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		snapshots, err := snapshot.GetNewSnapshotPaths()
+		snapshots, err := getPendingSnapshots(args)
 		if err != nil {
 			log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
 		}
 
+		if outputFormat != "text" {
+			outputs, err := snapshotOutputs(snapshots, "rejected")
+			if err != nil {
+				log.Fatal("An error ocurred while reading snapshots: ", err)
+			}
+			if _, err := snapshot.RejectAll(snapshots); err != nil {
+				log.Fatal("An error ocurred while rejecting snapshots: ", err)
+			}
+			if err := printSnapshotOutputs(outputs); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
 		if len(snapshots) == 0 {
 			fmt.Println("no snapshots to review")
 			return
 		}
 
-		rejectedSnaps, err := snapshot.RejectAll(snapshots)
-		if err != nil {
-			log.Fatal("An error ocurred while accepting snapshots: ", err)
-		}
-		ui.PrintReject(rejectedSnaps)
+		forEachModule(snapshots, func(snapshots []string) {
+			rejectedSnaps, err := snapshot.RejectAll(snapshots)
+			if err != nil {
+				log.Fatal("An error ocurred while accepting snapshots: ", err)
+			}
+			ui.PrintReject(rejectedSnaps)
+		})
 	},
 }
@@ -3,22 +3,29 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/LaBatata101/goinsta/internal/snapshot"
 	"github.com/LaBatata101/goinsta/internal/ui"
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// Review without the full-screen interface, reading the decisions from stdin.
+var nonInteractive bool
+
 func init() {
+	reviewCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false,
+		"print the diffs and read the decisions from stdin, the default when stdin or stdout isn't a terminal")
+	addFilterFlags(reviewCmd)
 	rootCmd.AddCommand(reviewCmd)
 }
 
 var reviewCmd = &cobra.Command{
-	Use:   "review",
+	Use:   "review [paths]",
 	Short: "Interactively review snapshots",
 	Run: func(cmd *cobra.Command, args []string) {
-		snapshots, err := snapshot.GetNewSnapshotPaths()
+		snapshots, err := getPendingSnapshots(args)
 		if err != nil {
 			log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
 		}
@@ -28,13 +35,31 @@ var reviewCmd = &cobra.Command{
 			return
 		}
 
-		rc := snapshot.Summary{}
-		model := ui.ReviewSnapshotsModel(snapshots, &rc)
-		p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-		if _, err := p.Run(); err != nil {
-			log.Fatal(err)
+		reviewSnapshots(snapshots)
+	},
+}
+
+// Runs the interactive review of the snapshots at `paths`, then prints the summary.
+func reviewSnapshots(paths []string) {
+	// Orphaned snapshots are offered to be moved over the new snapshots of renamed tests.
+	report, err := snapshot.FindOrphans(workspace.Dirs(), cfg.Ignore, os.Getenv(snapshot.ManifestEnv))
+	if err := deferScanError(err); err != nil {
+		log.Fatal("An error ocurred while looking for unreferenced snapshots: ", err)
+	}
+
+	rc := snapshot.Summary{}
+	if nonInteractive || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		width, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width = 100
 		}
+		err = ui.ReviewSnapshotsNonInteractive(paths, report.Orphans, &rc, os.Stdin, os.Stdout, width)
+	} else {
+		err = ui.ReviewSnapshots(paths, report.Orphans, &rc)
+	}
+	if err != nil {
+		log.Fatal("An error ocurred while applying the review, no snapshot was changed: ", err)
+	}
 
-		ui.PrintSummary(&rc)
-	},
+	ui.PrintSummary(&rc)
 }
@@ -1,15 +1,58 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/LaBatata101/goinsta/internal/config"
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/LaBatata101/goinsta/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+// Project configuration of the module containing the current directory.
+var cfg config.Config
+
+// Workspace whose snapshots are managed, see `config.FindWorkspace`.
+var workspace config.Workspace
+
+// Workspace root given with `--workspace`.
+var workspaceRoot string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&workspaceRoot, "workspace", "",
+		"root of the workspace whose snapshots are managed (default $"+config.WorkspaceRootEnv+", the go.work or go.mod directory)")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "goinsta",
 	Short: "A helper utility to manage goinsta snapshots",
+	// Errors are printed by `Execute`.
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		cfg, err = config.Load(".")
+		if err != nil {
+			return err
+		}
+
+		if workspaceRoot != "" {
+			workspace, err = config.LoadWorkspace(workspaceRoot)
+		} else {
+			workspace, err = config.FindWorkspace(".")
+		}
+		if err != nil {
+			return err
+		}
+		snapshot.WorkspaceRoot = workspace.Root
+
+		if cfg.ContextLines != nil {
+			snapshot.DiffContextLines = *cfg.ContextLines
+		}
+		return ui.Configure(cfg.Color, cfg.Theme)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			cmd.Help()
@@ -18,8 +61,26 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// Errors of the directories that couldn't be read while looking for snapshots, reported once the
+// command is done.
+var scanErrors []error
+
+// Records the errors of a `*snapshot.ScanError` to report them later, other errors are returned as is.
+func deferScanError(err error) error {
+	var scanErr *snapshot.ScanError
+	if errors.As(err, &scanErr) {
+		scanErrors = append(scanErrors, scanErr.Errors...)
+		return nil
+	}
+	return err
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if len(scanErrors) > 0 {
+		fmt.Fprintln(os.Stderr, ui.YellowText.Render("warning:"), (&snapshot.ScanError{Errors: scanErrors}).Error())
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/LaBatata101/goinsta/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	showNoColor bool
+	showWidth   int
+	showContext int
+	showRaw     bool
+)
+
+func init() {
+	showCmd.Flags().BoolVar(&showNoColor, "no-color", false, "disable colors")
+	showCmd.Flags().IntVar(&showWidth, "width", 0, "width of the output (default the terminal width, or 100)")
+	showCmd.Flags().IntVar(&showContext, "context", -1, "number of unchanged lines shown around each change of the diff")
+	showCmd.Flags().BoolVar(&showRaw, "raw", false, "print the plain diff, or the content of accepted snapshots, without the summary")
+	rootCmd.AddCommand(showCmd)
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show <path-or-name>",
+	Short: "Show a snapshot, with the diff against the accepted snapshot if it is pending",
+	Long: `Show a snapshot, with the diff against the accepted snapshot if it is pending.
+
+The snapshot is given by the path of a .snap, .snap.new or .pending-snap file, or by its name, like
+pkg.TestFoo. The pending snapshot is shown when a name matches both an accepted and a pending snapshot.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if showNoColor {
+			if err := ui.Configure("never", ""); err != nil {
+				return err
+			}
+		}
+		if showContext >= 0 {
+			snapshot.DiffContextLines = showContext
+		}
+
+		width := showWidth
+		if width <= 0 {
+			var err error
+			if width, _, err = term.GetSize(int(os.Stdout.Fd())); err != nil {
+				width = 100
+			}
+		}
+
+		paths, err := findSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			snap, err := snapshot.Read(path)
+			if err != nil {
+				return err
+			}
+
+			if !showRaw {
+				fmt.Println(ui.SnapshotSummary(&snap, width))
+			} else if snap.IsNew() {
+				fmt.Println(plainDiff(snap.Diff()))
+			} else {
+				fmt.Println(strings.TrimSuffix(snap.Content, "\n"))
+			}
+		}
+		return nil
+	},
+}
+
+// Returns the snapshot files at path `pathOrName`, or else of the snapshot named `pathOrName`.
+func findSnapshot(pathOrName string) ([]string, error) {
+	if _, err := os.Stat(pathOrName); err == nil {
+		path, err := filepath.Abs(pathOrName)
+		return []string{path}, err
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	paths, err := snapshot.FindByName(workspace.Dirs(), cfg.Ignore, pathOrName)
+	if err := deferScanError(err); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no snapshot file or snapshot named %q", pathOrName)
+	}
+	return paths, nil
+}
+
+// Drops the blank lines separating the lines of a diff.
+func plainDiff(diff string) string {
+	var lines []string
+	for _, line := range strings.Split(diff, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
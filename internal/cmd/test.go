@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/LaBatata101/goinsta/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewAfterTest bool
+	acceptAfterTest bool
+)
+
+func init() {
+	testCmd.Flags().BoolVar(&reviewAfterTest, "review", false, "interactively review the pending snapshots after the tests")
+	testCmd.Flags().BoolVar(&acceptAfterTest, "accept", false, "accept all pending snapshots after the tests")
+	testCmd.MarkFlagsMutuallyExclusive("review", "accept")
+	rootCmd.AddCommand(testCmd)
+}
+
+// Matches the output of a failed snapshot assertion.
+var snapshotFailurePattern = regexp.MustCompile(`Snapshot Summary|missing (inline )?snapshot |snapshot \S+ doesn't match|inline snapshot \S+ at \S+ doesn't match`)
+
+// An event of the `go test -json` output, see `go doc test2json`.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Output  string
+}
+
+// Outcome of a test, or of a package when `Test` is empty.
+type testResult struct {
+	Package string
+	Test    string
+	output  strings.Builder
+	// Whether one of the snapshot assertions of the test failed.
+	snapshotFailure bool
+}
+
+func (r *testResult) String() string {
+	if r.Test == "" {
+		return r.Package
+	}
+	return r.Package + " " + r.Test
+}
+
+// Outcome of a `go test` run.
+type testReport struct {
+	Passed           int
+	SnapshotFailures []*testResult
+	// Failed tests whose snapshot assertions passed, and packages that failed without a failing test,
+	// like those that don't build.
+	OtherFailures []*testResult
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [packages] [-- go test flags]",
+	Short: "Run the tests and report the snapshot assertions that failed",
+	Long: `Run the tests with ` + "`go test -json`" + ` and report the snapshot assertions that failed.
+
+The packages default to ./..., arguments after -- are passed to go test. With --review the pending
+snapshots of the tested packages are reviewed right after the tests, with --accept they are all
+accepted. After a review, the command fails if snapshots are left pending.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		packages, goTestFlags := args, []string(nil)
+		if dash := cmd.ArgsLenAtDash(); dash != -1 {
+			packages, goTestFlags = args[:dash], args[dash:]
+		}
+		if len(packages) == 0 {
+			packages = []string{"./..."}
+		}
+
+		// The shared run manifest is appended to by every package, it only lists the snapshots of this run.
+		if path := os.Getenv(snapshot.ManifestEnv); path != "" {
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				return err
+			}
+		}
+
+		report, err := runTests(append(append([]string{"test", "-json"}, goTestFlags...), packages...))
+		if err != nil {
+			return err
+		}
+		report.print()
+
+		// Only the snapshots of the tested packages are reviewed or accepted.
+		if filter.Packages, err = packageDirs(packages); err != nil {
+			return err
+		}
+		snapshots, err := getPendingSnapshots(nil)
+		if err != nil {
+			log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
+		}
+
+		switch {
+		case len(snapshots) == 0:
+		case acceptAfterTest:
+			acceptedSnaps, err := snapshot.AcceptAll(snapshots)
+			if err != nil {
+				log.Fatal("An error ocurred while accepting snapshots: ", err)
+			}
+			ui.PrintAccepted(acceptedSnaps)
+		case reviewAfterTest:
+			reviewSnapshots(snapshots)
+			// The snapshots rejected or skipped during the review are still failing.
+			if snapshots, err = getPendingSnapshots(nil); err != nil {
+				log.Fatal("An error ocurred while getting .snap.new snapshots: ", err)
+			}
+		default:
+			fmt.Printf("\n%d pending snapshots, review them with `goinsta review`\n", len(snapshots))
+		}
+
+		if len(report.OtherFailures) > 0 {
+			return fmt.Errorf("%d tests failed", len(report.OtherFailures)+len(report.SnapshotFailures))
+		}
+		if len(snapshots) > 0 && reviewAfterTest {
+			return fmt.Errorf("%d snapshots are still pending", len(snapshots))
+		}
+		if len(report.SnapshotFailures) > 0 && !acceptAfterTest && !reviewAfterTest {
+			return fmt.Errorf("%d tests failed because of snapshot assertions", len(report.SnapshotFailures))
+		}
+		return nil
+	},
+}
+
+// Returns the directories of the packages matching the `packages` patterns, as listed by `go list`.
+func packageDirs(packages []string) ([]string, error) {
+	listCmd := exec.Command("go", append([]string{"list", "-e", "-f", "{{.Dir}}"}, packages...)...)
+	listCmd.Stderr = os.Stderr
+	output, err := listCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the packages: %w", err)
+	}
+
+	var dirs []string
+	for _, dir := range strings.Split(string(output), "\n") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no packages match %s", strings.Join(packages, " "))
+	}
+	return dirs, nil
+}
+
+// Runs `go` with `args`, which must include `-json`, and collects the outcome of the tests.
+func runTests(args []string) (testReport, error) {
+	var report testReport
+	goCmd := exec.Command("go", args...)
+	goCmd.Stderr = os.Stderr
+	stdout, err := goCmd.StdoutPipe()
+	if err != nil {
+		return report, err
+	}
+	if err := goCmd.Start(); err != nil {
+		return report, err
+	}
+
+	results := make(map[string]*testResult)
+	failedPackages := make(map[string]bool)
+	failedParents := make(map[string]bool)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// Not an event, e.g. the output of a package that doesn't build.
+			fmt.Println(scanner.Text())
+			continue
+		}
+
+		if event.Action == "build-output" {
+			fmt.Print(event.Output)
+			continue
+		}
+
+		key := event.Package + " " + event.Test
+		result, ok := results[key]
+		if !ok {
+			result = &testResult{Package: event.Package, Test: event.Test}
+			results[key] = result
+		}
+
+		switch event.Action {
+		case "output":
+			result.output.WriteString(event.Output)
+			if snapshotFailurePattern.MatchString(event.Output) {
+				result.snapshotFailure = true
+			}
+		case "pass":
+			if event.Test != "" {
+				report.Passed++
+			}
+		case "fail":
+			if event.Test == "" {
+				if failedPackages[event.Package] {
+					continue
+				}
+			} else {
+				failedPackages[event.Package] = true
+				if i := strings.LastIndex(event.Test, "/"); i != -1 {
+					failedParents[event.Package+" "+event.Test[:i]] = true
+				}
+				// Parent tests fail along with their subtests, which are already reported.
+				if failedParents[key] && !result.snapshotFailure {
+					continue
+				}
+			}
+
+			if result.snapshotFailure {
+				report.SnapshotFailures = append(report.SnapshotFailures, result)
+			} else {
+				report.OtherFailures = append(report.OtherFailures, result)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	// `go test` exits with an error when tests fail, which is what is being reported.
+	var exitErr *exec.ExitError
+	if err := goCmd.Wait(); err != nil && !errors.As(err, &exitErr) {
+		return report, err
+	}
+	return report, nil
+}
+
+// Prints the failed tests, with the output of those that didn't fail because of a snapshot assertion.
+func (r testReport) print() {
+	for _, result := range r.OtherFailures {
+		fmt.Println(ui.RedText.Render("--- FAIL: ") + result.String())
+		fmt.Print(result.output.String())
+	}
+
+	if len(r.SnapshotFailures) > 0 {
+		fmt.Println(ui.YellowText.Render("Snapshot assertions failed") + ":")
+		for _, result := range r.SnapshotFailures {
+			fmt.Printf("  %s\n", result)
+		}
+	}
+
+	failed := len(r.SnapshotFailures) + len(r.OtherFailures)
+	if failed == 0 {
+		fmt.Println(ui.GreenText.Render(fmt.Sprintf("%d tests passed", r.Passed)))
+	} else {
+		fmt.Printf("%s, %s\n", ui.GreenText.Render(fmt.Sprintf("%d passed", r.Passed)),
+			ui.RedText.Render(fmt.Sprintf("%d failed", failed)))
+	}
+}
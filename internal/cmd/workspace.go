@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/LaBatata101/goinsta/internal/ui"
+)
+
+// Calls `f` with the `paths` of each module of the workspace, after printing the module name when the
+// workspace has several modules.
+func forEachModule(paths []string, f func(paths []string)) {
+	if len(workspace.Modules) < 2 {
+		f(paths)
+		return
+	}
+
+	modules, groups := workspace.GroupByModule(paths)
+	for _, module := range modules {
+		if module.Path == "" {
+			fmt.Println(ui.BoldText.Render("outside of the workspace modules"))
+		} else {
+			fmt.Printf("%s (%s)\n", ui.BoldText.Render("module "+module.Path), module.Dir)
+		}
+		f(groups[module.Dir])
+	}
+}
@@ -0,0 +1,164 @@
+// Package config loads the project level `goinsta` configuration file, shared by the `assert` package
+// and the `goinsta` CLI.
+//
+// The file is looked up in the module root, the first directory containing a `go.mod` file walking up
+// from the current directory. Both YAML and TOML are supported:
+//
+//	# goinsta.yaml
+//	snapshot_dir: testdata/snapshots
+//	update: new
+//	filters:
+//	  - pattern: '/tmp/[^/\s]+'
+//	    replacement: '[tmpdir]'
+//	color: auto
+//	theme: default
+//	context_lines: 3
+//	ignore:
+//	  - build
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the configuration file, in lookup order.
+var FileNames = []string{"goinsta.yaml", "goinsta.yml", ".goinsta.yaml", ".goinsta.yml", "goinsta.toml", ".goinsta.toml"}
+
+type Config struct {
+	// Directory where the snapshot files are stored, relative to each package directory.
+	SnapshotDir string `yaml:"snapshot_dir" toml:"snapshot_dir"`
+	// Default update mode, overridden by the `GOINSTA_UPDATE` environment variable.
+	Update string `yaml:"update" toml:"update"`
+	// Filters applied to every snapshot, before the ones given by the tests.
+	Filters []Filter `yaml:"filters" toml:"filters"`
+	// When to use colors: `auto`, `always` or `never`.
+	Color string `yaml:"color" toml:"color"`
+	// Color theme of the CLI: `default` or `high-contrast`.
+	Theme string `yaml:"theme" toml:"theme"`
+	// Number of unchanged lines shown around each change of a diff.
+	ContextLines *int `yaml:"context_lines" toml:"context_lines"`
+	// Directories skipped when scanning for snapshots, as names or glob patterns.
+	Ignore []string `yaml:"ignore" toml:"ignore"`
+
+	// Path of the loaded configuration file, empty if none was found.
+	Path string `yaml:"-" toml:"-"`
+}
+
+type Filter struct {
+	Pattern     string `yaml:"pattern" toml:"pattern"`
+	Replacement string `yaml:"replacement" toml:"replacement"`
+
+	regexp *regexp.Regexp
+}
+
+// Returns the compiled pattern of the filter.
+func (f Filter) Regexp() *regexp.Regexp {
+	return f.regexp
+}
+
+// Returns the module root of `dir`, the first directory containing a `go.mod` file walking up from it.
+func FindModuleRoot(dir string) (string, bool) {
+	currentDir := dir
+	for {
+		modPath := filepath.Join(currentDir, "go.mod")
+		_, err := os.Stat(modPath)
+		if err == nil {
+			return currentDir, true
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			break
+		}
+		currentDir = parentDir
+	}
+	return "", false
+}
+
+// Loads the configuration file of the module containing `dir`. An empty configuration is returned if
+// the module has no configuration file.
+func Load(dir string) (Config, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return Config{}, err
+	}
+
+	root, found := FindModuleRoot(absDir)
+	if !found {
+		return Config{}, nil
+	}
+
+	for _, name := range FileNames {
+		path := filepath.Join(root, name)
+		raw, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return Config{}, err
+		}
+
+		cfg, err := parse(path, raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+		cfg.Path = path
+		return cfg, nil
+	}
+
+	return Config{}, nil
+}
+
+func parse(path string, raw []byte) (Config, error) {
+	var cfg Config
+	if strings.HasSuffix(path, ".toml") {
+		meta, err := toml.NewDecoder(bytes.NewReader(raw)).Decode(&cfg)
+		if err != nil {
+			return Config{}, err
+		}
+		// Rejected like the YAML decoder does, so a misspelled key isn't silently dropped.
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return Config{}, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, err
+		}
+	}
+
+	for i, filter := range cfg.Filters {
+		re, err := regexp.Compile(filter.Pattern)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid filter pattern %q: %w", filter.Pattern, err)
+		}
+		cfg.Filters[i].regexp = re
+	}
+
+	switch cfg.Color {
+	case "", "auto", "always", "never":
+	default:
+		return Config{}, fmt.Errorf("invalid color %q, expected one of: auto, always, never", cfg.Color)
+	}
+
+	if cfg.ContextLines != nil && *cfg.ContextLines < 0 {
+		return Config{}, fmt.Errorf("invalid context_lines %d, it must not be negative", *cfg.ContextLines)
+	}
+
+	return cfg, nil
+}
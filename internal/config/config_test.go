@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUnknownKeys(t *testing.T) {
+	tests := []struct {
+		path    string
+		raw     string
+		wantErr string
+	}{
+		{"goinsta.yaml", "context_lines: 2\n", ""},
+		{"goinsta.yaml", "context_line: 2\n", "field context_line not found"},
+		{"goinsta.toml", "context_lines = 2\n", ""},
+		{"goinsta.toml", "context_line = 2\n", "unknown keys: context_line"},
+		{"goinsta.toml", "[[filters]]\npattern = 'x'\nreplace = 'y'\n", "unknown keys: filters.replace"},
+	}
+	for _, tt := range tests {
+		_, err := parse(tt.path, []byte(tt.raw))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("parse(%s, %q) error = %v", tt.path, tt.raw, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("parse(%s, %q) error = %v, want %q", tt.path, tt.raw, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Environment variable holding the workspace root, used instead of looking for it from the current
+// directory. Test binaries built with `go test -c` and run elsewhere use it to find the snapshot
+// directories of their packages.
+const WorkspaceRootEnv = "GOINSTA_WORKSPACE_ROOT"
+
+// A Go module of the workspace.
+type Module struct {
+	// Module path, as declared in its `go.mod` file.
+	Path string
+	// Absolute path of the module directory.
+	Dir string
+}
+
+// The modules whose snapshots are managed together.
+type Workspace struct {
+	// Absolute path of the workspace root.
+	Root    string
+	Modules []Module
+}
+
+// Returns the workspace containing `dir`: the `GOINSTA_WORKSPACE_ROOT` directory if it is set, otherwise
+// the first directory containing a `go.work` file walking up from `dir`, then its module root, then `dir`
+// itself.
+func FindWorkspace(dir string) (Workspace, error) {
+	if root := os.Getenv(WorkspaceRootEnv); root != "" {
+		return LoadWorkspace(root)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return Workspace{}, err
+	}
+	for currentDir := absDir; ; {
+		if _, err := os.Stat(filepath.Join(currentDir, "go.work")); err == nil {
+			return LoadWorkspace(currentDir)
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			break
+		}
+		currentDir = parentDir
+	}
+
+	if root, found := FindModuleRoot(absDir); found {
+		return LoadWorkspace(root)
+	}
+	return LoadWorkspace(absDir)
+}
+
+// Loads the workspace rooted at `root`. Its modules are those used by the `go.work` file of `root`,
+// or the module of `root`, or else every module found below `root`.
+func LoadWorkspace(root string) (Workspace, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return Workspace{}, err
+	}
+	workspace := Workspace{Root: root}
+
+	workPath := filepath.Join(root, "go.work")
+	raw, err := os.ReadFile(workPath)
+	if err == nil {
+		work, err := modfile.ParseWork(workPath, raw, nil)
+		if err != nil {
+			return Workspace{}, err
+		}
+		for _, use := range work.Use {
+			dir := use.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(root, dir)
+			}
+			module, err := readModule(dir)
+			if err != nil {
+				return Workspace{}, err
+			}
+			workspace.Modules = append(workspace.Modules, module)
+		}
+		return workspace, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Workspace{}, err
+	}
+
+	if module, err := readModule(root); err == nil {
+		workspace.Modules = []Module{module}
+		return workspace, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Workspace{}, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+
+		module, err := readModule(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		workspace.Modules = append(workspace.Modules, module)
+		return nil
+	})
+	return workspace, err
+}
+
+// Reads the module of the `go.mod` file in `dir`.
+func readModule(dir string) (Module, error) {
+	modPath := filepath.Join(dir, "go.mod")
+	raw, err := os.ReadFile(modPath)
+	if err != nil {
+		return Module{}, err
+	}
+	return Module{Path: modfile.ModulePath(raw), Dir: dir}, nil
+}
+
+// Returns the directories to search for snapshots: the workspace root, and the modules outside of it.
+func (w Workspace) Dirs() []string {
+	dirs := []string{w.Root}
+	for _, module := range w.Modules {
+		if !isSubpath(w.Root, module.Dir) {
+			dirs = append(dirs, module.Dir)
+		}
+	}
+	return dirs
+}
+
+// Returns the innermost module containing the file or directory at `path`.
+func (w Workspace) ModuleOf(path string) (Module, bool) {
+	var found Module
+	for _, module := range w.Modules {
+		if isSubpath(module.Dir, path) && len(module.Dir) > len(found.Dir) {
+			found = module
+		}
+	}
+	return found, found.Dir != ""
+}
+
+// Returns the directory of the package with import path `importPath`, if it belongs to one of the
+// modules of the workspace.
+func (w Workspace) PackageDir(importPath string) (string, bool) {
+	var found Module
+	for _, module := range w.Modules {
+		if (importPath == module.Path || strings.HasPrefix(importPath, module.Path+"/")) && len(module.Path) > len(found.Path) {
+			found = module
+		}
+	}
+	if found.Dir == "" {
+		return "", false
+	}
+	return filepath.Join(found.Dir, filepath.FromSlash(strings.TrimPrefix(importPath, found.Path))), true
+}
+
+// Groups `paths` by the module containing them, in the order of the module paths. Paths outside of
+// every module are grouped under an empty module.
+func (w Workspace) GroupByModule(paths []string) ([]Module, map[string][]string) {
+	groups := make(map[string][]string)
+	modules := make(map[string]Module)
+	for _, path := range paths {
+		module, _ := w.ModuleOf(path)
+		modules[module.Dir] = module
+		groups[module.Dir] = append(groups[module.Dir], path)
+	}
+
+	var sorted []Module
+	for _, module := range modules {
+		sorted = append(sorted, module)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	return sorted, groups
+}
+
+// Reports whether `path` is `dir` or is inside of it.
+func isSubpath(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -10,6 +10,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/redaction"
 )
 
 type dumpState struct {
@@ -20,6 +22,8 @@ type dumpState struct {
 	parentPointers    ptrmap
 	currentPointer    *ptrinfo
 	homePackageRegexp *regexp.Regexp
+	redactions        redaction.Redactions
+	path              []redaction.PathElem
 }
 
 func (s *dumpState) write(b []byte) {
@@ -45,6 +49,14 @@ func (s *dumpState) newlineWithPointerNameComment() {
 	s.write([]byte("\n"))
 }
 
+func (s *dumpState) pushPath(elem redaction.PathElem) {
+	s.path = append(s.path, elem)
+}
+
+func (s *dumpState) popPath() {
+	s.path = s.path[:len(s.path)-1]
+}
+
 func (s *dumpState) dumpType(v reflect.Value) {
 	typeName := v.Type().String()
 	s.write([]byte(typeName))
@@ -62,7 +74,9 @@ func (s *dumpState) dumpSlice(v reflect.Value) {
 	s.depth++
 	for i := 0; i < numEntries; i++ {
 		s.indent()
+		s.pushPath(redaction.Index(i))
 		s.dumpVal(v.Index(i))
+		s.popPath()
 		s.write([]byte(","))
 		s.newlineWithPointerNameComment()
 	}
@@ -90,7 +104,9 @@ func (s *dumpState) dumpStruct(v reflect.Value) {
 		s.indent()
 		s.write([]byte(vtf.Name))
 		s.write([]byte(": "))
+		s.pushPath(redaction.Field(vtf.Name))
 		s.dumpVal(v.Field(i))
+		s.popPath()
 		s.write([]byte(","))
 		s.newlineWithPointerNameComment()
 	}
@@ -128,7 +144,9 @@ func (s *dumpState) dumpMap(v reflect.Value) {
 		s.indent()
 		s.dumpVal(key)
 		s.write([]byte(": "))
+		s.pushPath(redaction.Field(mapKeyName(key)))
 		s.dumpVal(v.MapIndex(key))
+		s.popPath()
 		s.write([]byte(","))
 		s.newlineWithPointerNameComment()
 	}
@@ -137,6 +155,18 @@ func (s *dumpState) dumpMap(v reflect.Value) {
 	s.write([]byte("}"))
 }
 
+// Returns the name used to select the map entry of `key` in a redaction selector.
+func mapKeyName(key reflect.Value) string {
+	key = deInterface(key)
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+
+	buf := new(bytes.Buffer)
+	newDumpState(key, buf).dumpVal(key)
+	return buf.String()
+}
+
 func (s *dumpState) dumpFunc(v reflect.Value) {
 	parts := strings.Split(runtime.FuncForPC(v.Pointer()).Name(), "/")
 	name := parts[len(parts)-1]
@@ -198,6 +228,11 @@ func (s *dumpState) descendIntoPossiblePointer(value reflect.Value, f func()) {
 }
 
 func (s *dumpState) dumpVal(value reflect.Value) {
+	if replacement, ok := s.redactions.Lookup(s.path); ok {
+		s.writeString(strconv.Quote(replacement))
+		return
+	}
+
 	if value.Kind() == reflect.Ptr && value.IsNil() {
 		s.write([]byte("nil"))
 		return
@@ -316,19 +351,31 @@ func newDumpState(value reflect.Value, writer io.Writer) *dumpState {
 	return result
 }
 
+// Options that control how values are dumped.
+type Options struct {
+	// Values selected by a redaction are dumped as its (quoted) replacement.
+	Redactions redaction.Redactions
+}
+
 // Sdump dumps a value to a string according to the options
-func Sdump(values ...interface{}) string {
+func (o Options) Sdump(values ...interface{}) string {
 	buf := new(bytes.Buffer)
 	for i, value := range values {
 		if i > 0 {
 			_, _ = buf.Write([]byte(" "))
 		}
 		state := newDumpState(reflect.ValueOf(value), buf)
+		state.redactions = o.Redactions
 		state.dump(value)
 	}
 	return buf.String()
 }
 
+// Sdump dumps a value to a string using the default options
+func Sdump(values ...interface{}) string {
+	return Options{}.Sdump(values...)
+}
+
 type mapKeySorter struct {
 	keys []reflect.Value
 }
@@ -0,0 +1,152 @@
+// Package redaction implements the selectors used to replace dynamic values, such as timestamps or
+// UUIDs, with a fixed placeholder when a value is serialized into a snapshot.
+//
+// A selector is a path made of the following segments:
+//
+//	.Name    a struct field, or a map entry whose key is "Name"
+//	["key"]  a map entry whose key is "key"
+//	[N]      the N-th element of a slice or array
+//	[] [*]   any element of a slice, array or map
+//	.*       any struct field or map entry
+//
+// For example `.Items[].ID` selects the `ID` field of every element of the `Items` field.
+package redaction
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentName segmentKind = iota
+	segmentIndex
+	segmentAny
+)
+
+type segment struct {
+	kind  segmentKind
+	name  string
+	index int
+}
+
+// A parsed selector.
+type Selector struct {
+	raw      string
+	segments []segment
+}
+
+func (s Selector) String() string {
+	return s.raw
+}
+
+// Parses a selector such as `.Items[].ID`.
+func Parse(selector string) (Selector, error) {
+	var segments []segment
+	rest := selector
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+
+			switch name {
+			case "":
+				return Selector{}, fmt.Errorf("invalid selector %q: empty field name", selector)
+			case "*":
+				segments = append(segments, segment{kind: segmentAny})
+			default:
+				segments = append(segments, segment{kind: segmentName, name: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return Selector{}, fmt.Errorf("invalid selector %q: missing ']'", selector)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if inner == "" || inner == "*" {
+				segments = append(segments, segment{kind: segmentAny})
+			} else if key, err := strconv.Unquote(inner); err == nil {
+				segments = append(segments, segment{kind: segmentName, name: key})
+			} else if index, err := strconv.Atoi(inner); err == nil {
+				segments = append(segments, segment{kind: segmentIndex, index: index})
+			} else {
+				return Selector{}, fmt.Errorf("invalid selector %q: bad index %q", selector, inner)
+			}
+		default:
+			return Selector{}, fmt.Errorf("invalid selector %q: expected '.' or '[' at %q", selector, rest)
+		}
+	}
+
+	if len(segments) == 0 {
+		return Selector{}, fmt.Errorf("invalid selector %q: empty selector", selector)
+	}
+	return Selector{raw: selector, segments: segments}, nil
+}
+
+// An element of the path leading to the value being serialized.
+type PathElem struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// Returns the path element of a struct field or of a map entry.
+func Field(name string) PathElem {
+	return PathElem{name: name}
+}
+
+// Returns the path element of a slice or array element.
+func Index(index int) PathElem {
+	return PathElem{index: index, isIndex: true}
+}
+
+// Reports whether the selector selects the value at `path`.
+func (s Selector) Match(path []PathElem) bool {
+	if len(path) != len(s.segments) {
+		return false
+	}
+
+	for i, seg := range s.segments {
+		elem := path[i]
+		switch seg.kind {
+		case segmentName:
+			if elem.isIndex || elem.name != seg.name {
+				return false
+			}
+		case segmentIndex:
+			if !elem.isIndex || elem.index != seg.index {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Replaces the values selected by `Selector` with `Replacement`.
+type Redaction struct {
+	Selector    Selector
+	Replacement string
+}
+
+type Redactions []Redaction
+
+// Returns the replacement of the value at `path`, if any redaction selects it. When several
+// redactions select the same value, the last one wins.
+func (r Redactions) Lookup(path []PathElem) (string, bool) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i].Selector.Match(path) {
+			return r[i].Replacement, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,210 @@
+// Package serialize implements the structured snapshot formats (JSON, YAML, TOML and CSV).
+//
+// Values are first converted into a generic tree of maps, slices and scalars through their JSON
+// encoding, so struct tags and `json.Marshaler` implementations are honoured, and the output uses a
+// deterministic (sorted) key order no matter which format is produced.
+package serialize
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/LaBatata101/goinsta/internal/redaction"
+	"gopkg.in/yaml.v3"
+)
+
+// Converts `value` into a generic tree made of `map[string]any`, `[]any` and scalars, with the
+// values selected by `redactions` replaced.
+func toGeneric(value any, redactions redaction.Redactions) (any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return normalize(tree, nil, redactions), nil
+}
+
+// Converts the `json.Number`s of `node` into integers or floats and applies the redactions.
+func normalize(node any, path []redaction.PathElem, redactions redaction.Redactions) any {
+	if replacement, ok := redactions.Lookup(path); ok {
+		return replacement
+	}
+
+	switch node := node.(type) {
+	case map[string]any:
+		for key, value := range node {
+			node[key] = normalize(value, append(path, redaction.Field(key)), redactions)
+		}
+	case []any:
+		for i, value := range node {
+			node[i] = normalize(value, append(path, redaction.Index(i)), redactions)
+		}
+	case json.Number:
+		if n, err := node.Int64(); err == nil {
+			return n
+		}
+		if f, err := node.Float64(); err == nil {
+			return f
+		}
+		return node.String()
+	}
+	return node
+}
+
+// Serializes `value` as indented JSON.
+func JSON(value any, redactions redaction.Redactions) (string, error) {
+	tree, err := toGeneric(value, redactions)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tree); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Serializes `value` as YAML.
+func YAML(value any, redactions redaction.Redactions) (string, error) {
+	tree, err := toGeneric(value, redactions)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(tree); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Serializes `value` as TOML. The value must serialize into a JSON object, like a struct or a map.
+func TOML(value any, redactions redaction.Redactions) (string, error) {
+	tree, err := toGeneric(value, redactions)
+	if err != nil {
+		return "", err
+	}
+
+	table, ok := tree.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("TOML snapshots require a struct or map value, got %T", value)
+	}
+
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(table); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Serializes `value` as CSV. The value must be a slice whose elements are either rows (slices of
+// scalars) or records (structs or maps). Records get a header row made of their sorted keys.
+func CSV(value any, redactions redaction.Redactions) (string, error) {
+	tree, err := toGeneric(value, redactions)
+	if err != nil {
+		return "", err
+	}
+
+	rows, ok := tree.([]any)
+	if !ok {
+		return "", fmt.Errorf("CSV snapshots require a slice value, got %T", value)
+	}
+
+	var records [][]string
+	var header []string
+	for _, row := range rows {
+		switch row := row.(type) {
+		case []any:
+			record := make([]string, len(row))
+			for i, cell := range row {
+				record[i] = csvCell(cell)
+			}
+			records = append(records, record)
+		case map[string]any:
+			if header == nil {
+				header = csvHeader(rows)
+			}
+			record := make([]string, len(header))
+			for i, key := range header {
+				record[i] = csvCell(row[key])
+			}
+			records = append(records, record)
+		default:
+			return "", errors.New("CSV snapshots require a slice of rows, structs or maps")
+		}
+	}
+	if header != nil {
+		records = append([][]string{header}, records...)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(records); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// Returns the sorted union of the keys of every record in `rows`.
+func csvHeader(rows []any) []string {
+	seen := make(map[string]bool)
+	var header []string
+	for _, row := range rows {
+		record, ok := row.(map[string]any)
+		if !ok {
+			continue
+		}
+		for key := range record {
+			if !seen[key] {
+				seen[key] = true
+				header = append(header, key)
+			}
+		}
+	}
+	sort.Strings(header)
+	return header
+}
+
+func csvCell(cell any) string {
+	switch cell := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return cell
+	case bool:
+		return strconv.FormatBool(cell)
+	case int64:
+		return strconv.FormatInt(cell, 10)
+	case float64:
+		return strconv.FormatFloat(cell, 'g', -1, 64)
+	default:
+		// Nested values are stored as compact JSON.
+		raw, _ := json.Marshal(cell)
+		return string(raw)
+	}
+}
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Contents of the files that a decision on a snapshot may change, saved to undo it.
+type Backup struct {
+	files map[string]backupFile
+}
+
+type backupFile struct {
+	content []byte
+	mode    fs.FileMode
+	// The file didn't exist, restoring it deletes it.
+	missing bool
+}
+
+// Saves the files that accepting, rejecting or moving the snapshot may change: the pending and accepted
+// snapshot files, or the test source and its pending inline snapshots for inline snapshots, along with
+// the files of the `related` snapshots, like the orphaned snapshot of a move.
+func (s Snapshot) Backup(related ...Snapshot) (Backup, error) {
+	paths := []string{s.path, strings.TrimSuffix(s.path, ".new")}
+	if s.IsInline() {
+		pattern := filepath.Join(filepath.Dir(s.Source), "."+filepath.Base(s.Source)+".*"+pendingInlineExt)
+		pending, err := filepath.Glob(pattern)
+		if err != nil {
+			return Backup{}, err
+		}
+		paths = append(append(paths, s.Source), pending...)
+	}
+	for _, snap := range related {
+		paths = append(paths, snap.path)
+	}
+
+	b := Backup{files: make(map[string]backupFile)}
+	for _, path := range paths {
+		if _, ok := b.files[path]; ok {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			b.files[path] = backupFile{missing: true}
+			continue
+		} else if err != nil {
+			return Backup{}, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return Backup{}, err
+		}
+		b.files[path] = backupFile{content: content, mode: info.Mode().Perm()}
+	}
+	return b, nil
+}
+
+// Restores the saved files as they were when the backup was taken, deleting those that didn't exist.
+func (b Backup) Restore() error {
+	var errs []error
+	for path, file := range b.files {
+		if file.missing {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		errs = append(errs, os.WriteFile(path, file.content, file.mode))
+	}
+	return errors.Join(errs...)
+}
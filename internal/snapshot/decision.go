@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+)
+
+// What to do with a pending snapshot.
+type Action string
+
+const (
+	ActionAccept Action = "accept"
+	ActionReject Action = "reject"
+	ActionSkip   Action = "skip"
+	// Moves an orphaned snapshot over the pending one, see `Snapshot.Move`.
+	ActionMove Action = "move"
+)
+
+// A decision taken on a pending snapshot during a review.
+type Decision struct {
+	Snapshot Snapshot
+	Action   Action
+	// Orphaned snapshot moved over `Snapshot`, for moves.
+	From Snapshot
+}
+
+// Applies the decisions in order, then adds them to `summary`. If one of them fails, the files changed by
+// the decisions applied before it are restored and `summary` is left untouched, so a review is either
+// applied as a whole or not at all.
+func Apply(decisions []Decision, summary *Summary) error {
+	var backups []Backup
+	for _, d := range decisions {
+		var (
+			backup Backup
+			err    error
+		)
+		if d.Action == ActionMove {
+			backup, err = d.Snapshot.Backup(d.From)
+		} else {
+			backup, err = d.Snapshot.Backup()
+		}
+		if err == nil {
+			backups = append(backups, backup)
+			err = d.apply()
+		}
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("couldn't %s %s: %w", d.Action, d.Snapshot.CleanPath(), err)
+		// A failed decision may have changed some files already, its backup is restored too.
+		for i := len(backups) - 1; i >= 0; i-- {
+			if restoreErr := backups[i].Restore(); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("couldn't restore the snapshots: %w", restoreErr))
+			}
+		}
+		return err
+	}
+
+	for _, d := range decisions {
+		switch d.Action {
+		case ActionAccept:
+			summary.AddAccepted(d.Snapshot)
+		case ActionReject:
+			summary.AddRejected(d.Snapshot)
+		case ActionSkip:
+			summary.AddSkipped(d.Snapshot)
+		case ActionMove:
+			summary.AddMoved(d.From, d.Snapshot)
+		}
+	}
+	return nil
+}
+
+func (d Decision) apply() error {
+	switch d.Action {
+	case ActionAccept:
+		return d.Snapshot.Accept()
+	case ActionReject:
+		return d.Snapshot.Reject()
+	case ActionMove:
+		return d.Snapshot.Move(d.From)
+	}
+	return nil
+}
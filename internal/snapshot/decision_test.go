@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyRestoresOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		// Returns the decision that fails, taken after the others.
+		failing func(t *testing.T, dir string) Decision
+	}{
+		{
+			name: "read-only directory",
+			failing: func(t *testing.T, dir string) Decision {
+				if os.Geteuid() == 0 {
+					t.Skip("root ignores the directory permissions")
+				}
+				readOnly := filepath.Join(dir, "readonly")
+				if err := os.Mkdir(readOnly, 0755); err != nil {
+					t.Fatal(err)
+				}
+				snap := writePending(t, filepath.Join(readOnly, "pkg__TestC.snap"), "c")
+				if err := os.Chmod(readOnly, 0555); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Chmod(readOnly, 0755) })
+				return Decision{Snapshot: snap, Action: ActionAccept}
+			},
+		},
+		{
+			name: "pending file removed",
+			failing: func(t *testing.T, dir string) Decision {
+				snap := writePending(t, filepath.Join(dir, "pkg__TestC.snap"), "c")
+				if err := os.Remove(snap.path); err != nil {
+					t.Fatal(err)
+				}
+				return Decision{Snapshot: snap, Action: ActionAccept}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			accepted := filepath.Join(dir, "pkg__TestA.snap")
+			if err := os.Rename(writePending(t, accepted, "old a").path, accepted); err != nil {
+				t.Fatal(err)
+			}
+			changed := writePending(t, accepted, "new a")
+			added := writePending(t, filepath.Join(dir, "pkg__TestB.snap"), "b")
+			failing := tt.failing(t, dir)
+
+			var summary Summary
+			err := Apply([]Decision{
+				{Snapshot: changed, Action: ActionAccept},
+				{Snapshot: added, Action: ActionReject},
+				failing,
+			}, &summary)
+			if err == nil {
+				t.Fatal("Apply() succeeded, want an error")
+			}
+
+			if snap, err := Read(accepted); err != nil || snap.Content != "old a" {
+				t.Errorf("accepted snapshot = %q, %v, want the old content", snap.Content, err)
+			}
+			for _, snap := range []Snapshot{changed, added} {
+				if restored, err := Read(snap.path); err != nil || restored.Content != snap.Content {
+					t.Errorf("pending snapshot %s = %q, %v, want %q", snap.path, restored.Content, err, snap.Content)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "pkg__TestB.snap")); !os.IsNotExist(err) {
+				t.Errorf("rejected snapshot was accepted")
+			}
+			if len(summary.Accepted)+len(summary.Rejected)+len(summary.Skipped)+len(summary.Moved) > 0 {
+				t.Errorf("summary = %+v, want it untouched", summary)
+			}
+		})
+	}
+}
+
+// Writes a pending snapshot with `content` for the accepted snapshot at `path`.
+func writePending(t *testing.T, path, content string) Snapshot {
+	t.Helper()
+	snap, err := Write(path, Snapshot{Name: "pkg.Test", Source: "pkg_test.go", Loc: 1, Content: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snap
+}
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Selects pending snapshots. Empty fields select every snapshot.
+type Filter struct {
+	// Package directories of the tests, relative to the current directory. A trailing `/...` also
+	// selects the packages below the directory, like `go test` does.
+	Packages []string
+	// Glob patterns matched against the name of the test function, or against the full name of the
+	// test, like `TestFoo/sub*`, when the pattern contains a `/`.
+	Tests []string
+	// Glob patterns matched against the snapshot name, like `pkg.TestFoo*`.
+	Names []string
+	// Only select snapshots that don't replace an accepted one.
+	OnlyNew bool
+	// Only select snapshots that replace an accepted one.
+	OnlyChanged bool
+}
+
+// Matches the counter appended to the names of repeated snapshots of a test.
+var snapshotCounterPattern = regexp.MustCompile(`~\d+$`)
+
+// Returns the pending snapshots at `paths` selected by the filter.
+func (f Filter) Select(paths []string) ([]string, error) {
+	var selected []string
+	for _, snapPath := range paths {
+		snap, err := Read(snapPath)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := f.Match(snap)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, snapPath)
+		}
+	}
+	return selected, nil
+}
+
+// Reports whether the filter selects the pending snapshot `snap`. Returns an error if a glob pattern
+// is malformed.
+func (f Filter) Match(snap Snapshot) (bool, error) {
+	if f.OnlyNew && snap.HasAccepted() || f.OnlyChanged && !snap.HasAccepted() {
+		return false, nil
+	}
+
+	if len(f.Packages) > 0 {
+		ok, err := matchPackage(f.Packages, filepath.Dir(snap.Source))
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+
+	if len(f.Tests) > 0 {
+		ok, err := matchAny(f.Tests, func(pattern string) string {
+			return snapshotTestName(snap.Name, strings.Contains(pattern, "/"))
+		})
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+
+	if len(f.Names) > 0 {
+		ok, err := matchAny(f.Names, func(string) string { return snap.Name })
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Reports whether any of the glob `patterns` matches the name returned by `name` for the pattern.
+func matchAny(patterns []string, name func(pattern string) string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, name(pattern))
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Reports whether the package directory `dir` is selected by any of the package `patterns`.
+func matchPackage(patterns []string, dir string) (bool, error) {
+	for _, pattern := range patterns {
+		recursive := pattern == "..." || strings.HasSuffix(pattern, "/...")
+		pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+		if pattern == "" {
+			pattern = "."
+		}
+
+		patternDir, err := filepath.Abs(pattern)
+		if err != nil {
+			return false, err
+		}
+		if dir == patternDir || recursive && strings.HasPrefix(dir, patternDir+string(filepath.Separator)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Returns the name of the test that produced the snapshot named `name`, like `pkg.TestFoo.sub@suffix+name~2`.
+// The name of the test function is returned, or its full name, like `TestFoo/sub`, with `subtests`.
+func snapshotTestName(name string, subtests bool) string {
+	_, testName, _ := strings.Cut(name, ".")
+	testName = snapshotCounterPattern.ReplaceAllString(testName, "")
+	testName, _, _ = strings.Cut(testName, "@")
+	testName, _, _ = strings.Cut(testName, "+")
+	if !subtests {
+		testName, _, _ = strings.Cut(testName, ".")
+		return testName
+	}
+	return strings.ReplaceAll(testName, ".", "/")
+}
+
+// Reports whether the pending snapshot replaces an accepted snapshot, instead of being a new one.
+func (s Snapshot) HasAccepted() bool {
+	if s.IsInline() {
+		old, err := ReadInlineLiteral(s.Source, s.inlineLoc())
+		return err == nil && old != ""
+	}
+	_, err := os.Stat(strings.TrimSuffix(s.path, ".new"))
+	return err == nil
+}
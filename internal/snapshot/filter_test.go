@@ -0,0 +1,25 @@
+package snapshot
+
+import "testing"
+
+func TestSnapshotTestName(t *testing.T) {
+	tests := []struct {
+		name     string
+		subtests bool
+		want     string
+	}{
+		{"pkg.TestFoo", false, "TestFoo"},
+		{"pkg.TestFoo~2", false, "TestFoo"},
+		{"pkg.TestFoo.case-2", true, "TestFoo/case-2"},
+		{"pkg.TestFoo.case-2~3", true, "TestFoo/case-2"},
+		{"pkg.TestFoo.sub@v2~2", true, "TestFoo/sub"},
+		{"pkg.TestFoo.sub@v2", false, "TestFoo"},
+		{"pkg.TestFoo+name", true, "TestFoo"},
+		{"pkg.TestFoo.sub@v2+name~2", true, "TestFoo/sub"},
+	}
+	for _, tt := range tests {
+		if got := snapshotTestName(tt.name, tt.subtests); got != tt.want {
+			t.Errorf("snapshotTestName(%q, %v) = %q, want %q", tt.name, tt.subtests, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A pattern of a `.gitignore` file.
+type ignoreRule struct {
+	// Directory of the `.gitignore` file, patterns are matched against paths relative to it.
+	base    string
+	regexp  *regexp.Regexp
+	negated bool
+}
+
+// Reads the patterns of the `.gitignore` file in `dir`, if any.
+func readGitignore(dir string) ([]ignoreRule, error) {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(dir, scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// Parses a `.gitignore` line into a rule. Returns false for blank lines and comments.
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negated = true
+		line = line[1:]
+	}
+	if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	// Only directories are matched against the rules, so `dir/` and `dir` are the same.
+	line = strings.TrimSuffix(line, "/")
+
+	// Patterns without a slash match at any depth, the others are relative to the `.gitignore` directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**"):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			b.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.regexp = re
+	return rule, true
+}
+
+// Reports whether the `.gitignore` rules ignore the directory at `path`. Later rules take precedence
+// over earlier ones, like in git.
+func gitignoredDir(rules []ignoreRule, path string) bool {
+	ignored := false
+	for _, rule := range rules {
+		relPath, err := filepath.Rel(rule.base, path)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		if rule.regexp.MatchString(filepath.ToSlash(relPath)) {
+			ignored = !rule.negated
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import "testing"
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// Patterns without a slash match at any depth.
+		{"build", "build", true},
+		{"build", "a/b/build", true},
+		{"build", "builder", false},
+		{"build/", "a/build", true},
+		// Patterns with a slash are anchored to the `.gitignore` directory.
+		{"/build", "build", true},
+		{"/build", "a/build", false},
+		{"a/build", "a/build", true},
+		{"a/build", "x/a/build", false},
+		{"*.tmp", "a/cache.tmp", true},
+		{"a/*", "a/b", true},
+		{"a/*", "a/b/c", false},
+		{"a?c", "abc", true},
+		{"a?c", "a/c", false},
+		// `**` matches any number of directories.
+		{"**/build", "build", true},
+		{"**/build", "a/b/build", true},
+		{"a/**", "a/b/c", true},
+		{"a/**", "a", true},
+		{"a/**/z", "a/z", true},
+		{"a/**/z", "a/b/c/z", true},
+		{"a/**/z", "b/z", false},
+		// Character classes, `[!…]` is negated.
+		{"[abc]x", "bx", true},
+		{"[abc]x", "dx", false},
+		{"[!abc]x", "dx", true},
+		{"[!abc]x", "ax", false},
+		{"[a-c]x", "cx", true},
+		{"[ab", "[ab", true},
+		// Escaped special characters.
+		{`\!important`, "!important", true},
+		{`\#tmp`, "#tmp", true},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{"a.b", "axb", false},
+	}
+	for _, tt := range tests {
+		rule, ok := parseIgnoreRule("/repo", tt.pattern)
+		if !ok {
+			t.Errorf("parseIgnoreRule(%q) returned no rule", tt.pattern)
+			continue
+		}
+		if got := rule.regexp.MatchString(tt.path); got != tt.want {
+			t.Errorf("pattern %q (%s) matches %q = %v, want %v", tt.pattern, rule.regexp, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseIgnoreRuleSkipped(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment"} {
+		if _, ok := parseIgnoreRule("/repo", line); ok {
+			t.Errorf("parseIgnoreRule(%q) returned a rule", line)
+		}
+	}
+}
+
+func TestGitignoredDir(t *testing.T) {
+	var rules []ignoreRule
+	for _, line := range []string{"gen*", "!generated", "/out"} {
+		rule, _ := parseIgnoreRule("/repo", line)
+		rules = append(rules, rule)
+	}
+	nested, _ := parseIgnoreRule("/repo/sub", "out")
+	rules = append(rules, nested)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/repo/gen", true},
+		{"/repo/a/genfiles", true},
+		// Negated rules take precedence over the earlier rules.
+		{"/repo/generated", false},
+		{"/repo/a/generated", false},
+		{"/repo/out", true},
+		{"/repo/a/out", false},
+		// The rules of a nested `.gitignore` are relative to its directory.
+		{"/repo/sub/out", true},
+		{"/repo/sub/a/out", true},
+		{"/other/gen", false},
+	}
+	for _, tt := range tests {
+		if got := gitignoredDir(rules, tt.path); got != tt.want {
+			t.Errorf("gitignoredDir(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
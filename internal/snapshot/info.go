@@ -0,0 +1,49 @@
+package snapshot
+
+import "strings"
+
+// Description of a pending snapshot, for the machine-readable output of the CLI.
+type Info struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	// Path of the accepted snapshot, which is the test source for inline snapshots.
+	OldPath string `json:"old_path"`
+	// Path of the pending snapshot.
+	NewPath string `json:"new_path"`
+	Inline  bool   `json:"inline"`
+	// `new` if the snapshot doesn't replace an accepted one, `changed` otherwise.
+	Status  string `json:"status"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// Returns the description of the pending snapshot.
+func (s Snapshot) Info() Info {
+	info := Info{
+		Name:    s.Name,
+		Source:  s.Source,
+		Line:    s.Loc,
+		OldPath: strings.TrimSuffix(s.path, ".new"),
+		NewPath: s.path,
+		Inline:  s.IsInline(),
+		Status:  "new",
+	}
+	if s.IsInline() {
+		info.Line = s.inlineLoc()
+		info.OldPath = s.Source
+	}
+	if s.HasAccepted() {
+		info.Status = "changed"
+	}
+
+	for _, line := range strings.Split(s.Diff(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			info.Added++
+		case strings.HasPrefix(line, "-"):
+			info.Removed++
+		}
+	}
+	return info
+}
@@ -0,0 +1,274 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name of the function whose call holds the inline snapshot literal as its third argument.
+const inlineSnapshotFuncName = "InlineSnapshot"
+
+// Returned when the expected value of an inline snapshot isn't a string literal, like the parameter of a
+// helper wrapping `InlineSnapshot`, which can't be rewritten without changing every caller of the helper.
+var ErrInlineNotLiteral = errors.New("inline snapshot is not a string literal")
+
+// Returns the path of the pending inline snapshot for the assertion at `source:loc`. The file is placed
+// next to the test source file and named after the index of the `InlineSnapshot` call in the source,
+// which, unlike its line, doesn't change when the literals of other inline snapshots are rewritten. The
+// line is used instead if the source can't be parsed.
+func InlinePendingPath(source string, loc int) string {
+	key := loc
+	if call, err := parseInlineCall(source, loc); err == nil {
+		key = call.index
+	}
+	return filepath.Join(filepath.Dir(source), fmt.Sprintf(".%s.%d%s", filepath.Base(source), key, pendingInlineExt))
+}
+
+// Writes `snap` as a `.pending-snap` snapshot next to its source file, recording the new value of the
+// inline snapshot asserted at `snap.Source:snap.Loc`.
+func WriteInline(snap Snapshot) (Snapshot, error) {
+	return writeInline(InlinePendingPath(snap.Source, snap.Loc), snap)
+}
+
+func writeInline(path string, snap Snapshot) (Snapshot, error) {
+	snap.path = path
+	err := write(path, snap.Content, snap.header())
+	return snap, err
+}
+
+// Returns the current assertion line of an inline snapshot. It may have moved since `s` was read, if
+// other inline snapshots of the same source file were accepted in the meantime.
+func (s Snapshot) inlineLoc() int {
+	if current, err := Read(s.path); err == nil {
+		return current.Loc
+	}
+	return s.Loc
+}
+
+// Normalizes the value of an inline snapshot literal, so that the literal can be freely indented and
+// start or end on its own line inside the test source.
+func NormalizeInline(literal string) string {
+	lines := strings.Split(literal, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		}
+	}
+
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// Returns the normalized value of the inline snapshot literal asserted at `source:loc`.
+func ReadInlineLiteral(source string, loc int) (string, error) {
+	call, err := parseInlineCall(source, loc)
+	if err != nil {
+		return "", err
+	}
+
+	lit, err := call.literal()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", err
+	}
+	return NormalizeInline(value), nil
+}
+
+// An `InlineSnapshot` call of a parsed test source file.
+type inlineCall struct {
+	source string
+	src    []byte
+	fset   *token.FileSet
+	call   *ast.CallExpr
+	// Index of the call among the `InlineSnapshot` calls of the source file, in source order.
+	index int
+}
+
+// Parses `source` and finds the `InlineSnapshot` call asserted at line `loc`.
+func parseInlineCall(source string, loc int) (inlineCall, error) {
+	src, err := os.ReadFile(source)
+	if err != nil {
+		return inlineCall{}, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, src, parser.ParseComments)
+	if err != nil {
+		return inlineCall{}, err
+	}
+
+	call, index, err := findInlineCall(fset, file, loc)
+	if err != nil {
+		return inlineCall{}, err
+	}
+	return inlineCall{source: source, src: src, fset: fset, call: call, index: index}, nil
+}
+
+// Returns the literal holding the inline snapshot, or an error wrapping `ErrInlineNotLiteral` if the
+// expected value is any other expression.
+func (c inlineCall) literal() (*ast.BasicLit, error) {
+	lit, ok := c.call.Args[2].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, fmt.Errorf("%s:%d: %w", c.source, c.fset.Position(c.call.Pos()).Line, ErrInlineNotLiteral)
+	}
+	return lit, nil
+}
+
+// Finds the innermost `InlineSnapshot` call spanning line `loc`, and its index among the `InlineSnapshot`
+// calls of `file`.
+func findInlineCall(fset *token.FileSet, file *ast.File, loc int) (*ast.CallExpr, int, error) {
+	var calls []*ast.CallExpr
+	found := -1
+	var foundSpan int
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) < 3 {
+			return true
+		}
+
+		var name string
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			name = fun.Sel.Name
+		case *ast.Ident:
+			name = fun.Name
+		}
+		if name != inlineSnapshotFuncName {
+			return true
+		}
+
+		start, end := fset.Position(call.Pos()).Line, fset.Position(call.End()).Line
+		if start <= loc && loc <= end && (found == -1 || end-start < foundSpan) {
+			found, foundSpan = len(calls), end-start
+		}
+		calls = append(calls, call)
+		return true
+	})
+
+	if found == -1 {
+		return nil, 0, fmt.Errorf("%s:%d: no %s call found", fset.File(file.Pos()).Name(), loc, inlineSnapshotFuncName)
+	}
+	return calls[found], found, nil
+}
+
+// Replaces the inline snapshot literal asserted at `source:loc` with `content`, leaving the rest of the
+// source untouched. Returns how many lines the literal, and so the source file, grew (or shrank, if
+// negative) by.
+//
+// Only string literals are rewritten, an error wrapping `ErrInlineNotLiteral` is returned for any other
+// expression.
+func RewriteInline(source string, loc int, content string) (int, error) {
+	call, err := parseInlineCall(source, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	lit, err := call.literal()
+	if err != nil {
+		return 0, err
+	}
+	src := call.src
+	start, end := call.fset.Position(lit.Pos()).Offset, call.fset.Position(lit.End()).Offset
+
+	literal := inlineLiteral(content)
+	var b bytes.Buffer
+	b.Write(src[:start])
+	b.WriteString(literal)
+	b.Write(src[end:])
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(source, b.Bytes(), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+
+	return strings.Count(literal, "\n") - bytes.Count(src[start:end], []byte("\n")), nil
+}
+
+// Returns the Go string literal used to store `content` in the test source.
+func inlineLiteral(content string) string {
+	switch {
+	case strings.ContainsAny(content, "`\r"):
+		return strconv.Quote(content)
+	case strings.Contains(content, "\n"):
+		return "`\n" + content + "\n`"
+	default:
+		return "`" + content + "`"
+	}
+}
+
+func acceptInline(s Snapshot) error {
+	loc := s.inlineLoc()
+	delta, err := RewriteInline(s.Source, loc, s.Content)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path); err != nil {
+		return err
+	}
+
+	if delta != 0 {
+		return shiftPendingInline(s.Source, loc, delta)
+	}
+	return nil
+}
+
+// Moves the assertion line of every pending inline snapshot of `source` located after line `loc` by
+// `delta` lines, keeping them in sync after the source file was rewritten. The pending files are named
+// after the index of their call, which doesn't change, so only their header is updated.
+func shiftPendingInline(source string, loc, delta int) error {
+	pattern := filepath.Join(filepath.Dir(source), "."+filepath.Base(source)+".*"+pendingInlineExt)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		snap, err := Read(path)
+		if err != nil {
+			return err
+		}
+		if snap.Source != source || snap.Loc <= loc {
+			continue
+		}
+		snap.Loc += delta
+		if _, err := writeInline(path, snap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
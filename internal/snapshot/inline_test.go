@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcceptInlineKeepsSourceFormatting(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "demo_test.go")
+	// Not gofmt'd: the alignment and spacing must survive the rewrites.
+	src := "package demo\n" +
+		"\n" +
+		"import \"testing\"\n" +
+		"\n" +
+		"func TestDemo(t *testing.T) {\n" +
+		"\tx :=   1\n" +
+		"\tassert.InlineSnapshot(t, x, ``)\n" +
+		"\tassert.InlineSnapshot(t,  \"y\", ``)\n" +
+		"}\n"
+	if err := os.WriteFile(source, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, snap := range []Snapshot{
+		{Name: "demo.TestDemo", Source: source, Loc: 7, Content: "line1\nline2"},
+		{Name: "demo.TestDemo", Source: source, Loc: 8, Content: "y"},
+	} {
+		written, err := WriteInline(snap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, written.path)
+	}
+
+	if _, err := AcceptAll(paths); err != nil {
+		t.Fatalf("AcceptAll() error = %v", err)
+	}
+
+	got, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package demo\n" +
+		"\n" +
+		"import \"testing\"\n" +
+		"\n" +
+		"func TestDemo(t *testing.T) {\n" +
+		"\tx :=   1\n" +
+		"\tassert.InlineSnapshot(t, x, `\n" +
+		"line1\n" +
+		"line2\n" +
+		"`)\n" +
+		"\tassert.InlineSnapshot(t,  \"y\", `y`)\n" +
+		"}\n"
+	if string(got) != want {
+		t.Errorf("source after accepting:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("pending snapshot %s wasn't removed", path)
+		}
+	}
+}
+
+func TestRewriteInlineDelta(t *testing.T) {
+	tests := []struct {
+		name    string
+		literal string
+		content string
+		want    int
+	}{
+		{"single line", "``", "value", 0},
+		{"grows", "``", "a\nb", 3},
+		{"shrinks", "`\na\nb\nc\n`", "a", -4},
+		{"quoted", "``", "a`b\nc", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := filepath.Join(t.TempDir(), "demo_test.go")
+			src := "package demo\n\nfunc TestDemo(t *testing.T) {\n\tassert.InlineSnapshot(t, 1, " + tt.literal + ")\n}\n"
+			if err := os.WriteFile(source, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			delta, err := RewriteInline(source, 4, tt.content)
+			if err != nil {
+				t.Fatalf("RewriteInline() error = %v", err)
+			}
+			if delta != tt.want {
+				t.Errorf("RewriteInline() = %d, want %d", delta, tt.want)
+			}
+			if got, err := ReadInlineLiteral(source, 4); err != nil || got != tt.content {
+				t.Errorf("ReadInlineLiteral() = %q, %v, want %q", got, err, tt.content)
+			}
+		})
+	}
+}
+
+func TestRewriteInlineRejectsNonLiteral(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "demo_test.go")
+	src := "package demo\n" +
+		"\n" +
+		"func check(t *testing.T, v any, want string) {\n" +
+		"\tassert.InlineSnapshot(t, v, want)\n" +
+		"}\n"
+	if err := os.WriteFile(source, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RewriteInline(source, 4, "2"); !errors.Is(err, ErrInlineNotLiteral) {
+		t.Errorf("RewriteInline() error = %v, want ErrInlineNotLiteral", err)
+	}
+	if got, _ := os.ReadFile(source); string(got) != src {
+		t.Errorf("source was rewritten:\n%s", got)
+	}
+}
+
+func TestInlinePendingPathAfterAccept(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "demo_test.go")
+	src := "package demo\n" +
+		"\n" +
+		"func TestDemo(t *testing.T) {\n" +
+		"\tassert.InlineSnapshot(t, 1, ``)\n" +
+		"\tassert.InlineSnapshot(t, 2, ``)\n" +
+		"}\n"
+	if err := os.WriteFile(source, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := WriteInline(Snapshot{Name: "demo.TestDemo", Source: source, Loc: 4, Content: "a\nb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := WriteInline(Snapshot{Name: "demo.TestDemo", Source: source, Loc: 5, Content: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Accept(); err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+
+	// The second assertion moved down, the next run must find its pending snapshot where it is.
+	pending, err := Read(second.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Loc != 8 {
+		t.Errorf("assertion line = %d, want 8", pending.Loc)
+	}
+	if got := InlinePendingPath(source, pending.Loc); got != second.path {
+		t.Errorf("InlinePendingPath() = %s, want %s", got, second.path)
+	}
+}
@@ -0,0 +1,207 @@
+package snapshot
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Name of the run manifest written in every snapshot directory, listing the snapshots asserted
+	// during the last test run of the package.
+	ManifestFileName = ".goinsta-manifest"
+	// Environment variable holding the path of a single run manifest shared by every package, used
+	// instead of the per-directory manifests when set.
+	ManifestEnv = "GOINSTA_MANIFEST"
+	// Line written at the top of the entries recorded by a test process that only ran some of the
+	// tests, with `-run` or `-skip`.
+	partialRunMarker = "# partial run"
+)
+
+// Run manifests already started by this process, by snapshot directory for the per-directory ones and
+// by path for the shared one.
+var startedManifests sync.Map
+
+var manifestMu sync.Mutex
+
+// Records in the run manifest that the snapshot file at `snapshotPath` was asserted during this run.
+// The per-directory manifest is truncated the first time the process records a reference in it, so
+// it only lists the snapshots of the latest run. The shared manifest is written by every package of the
+// run and is only appended to, it must be emptied before the run, as `goinsta test` does.
+//
+// When the tests were selected with `-run` or `-skip`, the manifest is marked as written by a partial
+// run, so the snapshots of the tests that didn't run aren't taken for orphans.
+func RecordReference(snapshotPath string) error {
+	snapshotPath, err := filepath.Abs(snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	var manifestPath, entry string
+	var started bool
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if path := os.Getenv(ManifestEnv); path != "" {
+		manifestPath, entry = path, snapshotPath
+		_, started = startedManifests.LoadOrStore(path, true)
+	} else {
+		dir := filepath.Dir(snapshotPath)
+		manifestPath, entry = filepath.Join(dir, ManifestFileName), filepath.Base(snapshotPath)
+		if _, started = startedManifests.LoadOrStore(dir, true); !started {
+			flags |= os.O_TRUNC
+		}
+	}
+	if !started && isPartialRun() {
+		entry = partialRunMarker + "\n" + entry
+	}
+
+	file, err := os.OpenFile(manifestPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(entry + "\n")
+	return err
+}
+
+// Reports whether the tests of this process were selected with `-run` or `-skip`.
+func isPartialRun() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Content of a run manifest.
+type manifest struct {
+	// Absolute paths of the snapshot files asserted during the run.
+	references map[string]bool
+	// Directories of the referenced snapshot files.
+	dirs map[string]bool
+	// Whether a test process of the run only ran some of the tests.
+	partial bool
+}
+
+// Reads the run manifest at `manifestPath`. Relative entries are resolved against the directory of the
+// manifest.
+func readManifest(manifestPath string) (manifest, error) {
+	m := manifest{references: make(map[string]bool), dirs: make(map[string]bool)}
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return m, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == partialRunMarker {
+			m.partial = true
+		}
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(filepath.Dir(manifestPath), entry)
+		}
+		m.references[entry] = true
+		m.dirs[filepath.Dir(entry)] = true
+	}
+	return m, scanner.Err()
+}
+
+// Result of comparing the run manifests against the snapshot files on disk.
+type OrphanReport struct {
+	// Snapshot files (`.snap` and `.snap.new`) no test asserted during the last run.
+	Orphans []string
+	// Snapshot directories without a run manifest, or that no snapshot of the shared manifest belongs to,
+	// whose snapshots couldn't be checked.
+	Unchecked []string
+	// Run manifests written by a run that only ran some of the tests, whose snapshots weren't checked.
+	Partial []string
+}
+
+// Finds the snapshot files in `dirs` and their sub-directories that aren't referenced by the run
+// manifest of their directory, or by the shared manifest at `manifestPath` if it isn't empty.
+// Directories are skipped like `findFiles` does, and unreadable ones are reported with a `*ScanError`,
+// returned along with the report.
+//
+// Only the snapshots of complete runs are checked: the directories whose manifest is missing or was
+// written by a partial run are reported instead, and so are, with a shared manifest, the directories
+// without any snapshot asserted during the run, whose package probably wasn't tested.
+func FindOrphans(dirs, ignore []string, manifestPath string) (OrphanReport, error) {
+	var report OrphanReport
+	var paths []string
+	var scanErr ScanError
+	for _, dir := range dirs {
+		found, err := findFiles(dir, ignore, snapshotExt, newSnapshotExt)
+		if errs, ok := err.(*ScanError); ok {
+			scanErr.Errors = append(scanErr.Errors, errs.Errors...)
+		} else if err != nil {
+			return report, err
+		}
+		paths = append(paths, found...)
+	}
+
+	var shared *manifest
+	if manifestPath != "" {
+		m, err := readManifest(manifestPath)
+		if err != nil {
+			return report, err
+		}
+		if m.partial {
+			report.Partial = append(report.Partial, manifestPath)
+			paths = nil
+		}
+		shared = &m
+	}
+
+	// The manifest of every directory, nil when its snapshots can't be checked.
+	manifests := make(map[string]*manifest)
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		m, ok := manifests[dir]
+		if !ok {
+			m = shared
+			if shared != nil && !shared.dirs[dir] {
+				report.Unchecked = append(report.Unchecked, dir)
+				m = nil
+			} else if shared == nil {
+				dirManifestPath := filepath.Join(dir, ManifestFileName)
+				dirManifest, err := readManifest(dirManifestPath)
+				switch {
+				case errors.Is(err, fs.ErrNotExist):
+					report.Unchecked = append(report.Unchecked, dir)
+				case err != nil:
+					return report, err
+				case dirManifest.partial:
+					report.Partial = append(report.Partial, dirManifestPath)
+				default:
+					m = &dirManifest
+				}
+			}
+			manifests[dir] = m
+		}
+
+		if m != nil && !m.references[strings.TrimSuffix(path, ".new")] {
+			report.Orphans = append(report.Orphans, path)
+		}
+	}
+
+	sort.Strings(report.Orphans)
+	if len(scanErr.Errors) > 0 {
+		return report, &scanErr
+	}
+	return report, nil
+}
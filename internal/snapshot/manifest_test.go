@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Creates the `files`, by path relative to `root`, with their parent directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	root := t.TempDir()
+	sharedPath := filepath.Join(root, "shared-manifest")
+	tests := []struct {
+		name string
+		// Files to create in the test directory, `$ROOT` is replaced in the shared manifest.
+		files     map[string]string
+		shared    string
+		orphans   []string
+		unchecked []string
+		partial   []string
+	}{
+		{
+			name: "directory manifest",
+			files: map[string]string{
+				"a/" + ManifestFileName:   "pkg__TestA.snap\npkg__TestB.snap\n",
+				"a/pkg__TestA.snap":       "",
+				"a/pkg__TestB.snap.new":   "",
+				"a/pkg__TestOld.snap":     "",
+				"a/pkg__TestNew.snap.new": "",
+			},
+			orphans: []string{"a/pkg__TestNew.snap.new", "a/pkg__TestOld.snap"},
+		},
+		{
+			name: "missing manifest",
+			files: map[string]string{
+				"a/" + ManifestFileName: "pkg__TestA.snap\n",
+				"a/pkg__TestA.snap":     "",
+				"b/pkg__TestB.snap":     "",
+			},
+			unchecked: []string{"b"},
+		},
+		{
+			name: "partial run",
+			files: map[string]string{
+				"a/" + ManifestFileName: partialRunMarker + "\npkg__TestA.snap\n",
+				"a/pkg__TestA.snap":     "",
+				"a/pkg__TestB.snap":     "",
+			},
+			partial: []string{"a/" + ManifestFileName},
+		},
+		{
+			name: "shared manifest",
+			files: map[string]string{
+				"a/pkg__TestA.snap":   "",
+				"a/pkg__TestOld.snap": "",
+				"b/pkg__TestB.snap":   "",
+				// Ignored in favour of the shared manifest.
+				"b/" + ManifestFileName: "",
+			},
+			shared:    "$ROOT/a/pkg__TestA.snap\n",
+			orphans:   []string{"a/pkg__TestOld.snap"},
+			unchecked: []string{"b"},
+		},
+		{
+			name: "shared manifest of a partial run",
+			files: map[string]string{
+				"a/pkg__TestA.snap":   "",
+				"a/pkg__TestOld.snap": "",
+			},
+			shared:  "$ROOT/a/pkg__TestA.snap\n" + partialRunMarker + "\n",
+			partial: []string{sharedPath},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			manifestPath := ""
+			if tt.shared != "" {
+				manifestPath = sharedPath
+				shared := []byte(strings.ReplaceAll(tt.shared, "$ROOT", dir))
+				if err := os.WriteFile(manifestPath, shared, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			report, err := FindOrphans([]string{dir}, nil, manifestPath)
+			if err != nil {
+				t.Fatalf("FindOrphans() error = %v", err)
+			}
+
+			join := func(paths []string) []string {
+				var joined []string
+				for _, path := range paths {
+					if !filepath.IsAbs(path) {
+						path = filepath.Join(dir, path)
+					}
+					joined = append(joined, path)
+				}
+				return joined
+			}
+			if want := join(tt.orphans); !slices.Equal(report.Orphans, want) {
+				t.Errorf("Orphans = %v, want %v", report.Orphans, want)
+			}
+			if want := join(tt.unchecked); !slices.Equal(report.Unchecked, want) {
+				t.Errorf("Unchecked = %v, want %v", report.Unchecked, want)
+			}
+			if want := join(tt.partial); !slices.Equal(report.Partial, want) {
+				t.Errorf("Partial = %v, want %v", report.Partial, want)
+			}
+		})
+	}
+}
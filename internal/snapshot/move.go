@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/gotextdiff"
+)
+
+// Minimum similarity between an orphaned snapshot and a new one for the pair to be offered as a move.
+const MoveSimilarityThreshold = 0.8
+
+// An orphaned snapshot that probably belongs to the test of a new snapshot, before it was renamed.
+type MoveCandidate struct {
+	From Snapshot
+	// How close the contents of both snapshots are, between 0 and 1.
+	Similarity float64
+}
+
+// Pairs the new snapshots in `snapshots`, those that don't replace an accepted snapshot, with the
+// orphaned snapshot files in `orphans` whose content is the most similar. Each orphan is paired at most
+// once. Returns the candidates keyed by the index of the new snapshot in `snapshots`.
+func FindMoveCandidates(snapshots []Snapshot, orphans []string) map[int]MoveCandidate {
+	var orphanSnaps []Snapshot
+	for _, path := range orphans {
+		if !strings.HasSuffix(path, snapshotExt) {
+			continue
+		}
+		if snap, err := Read(path); err == nil {
+			orphanSnaps = append(orphanSnaps, snap)
+		}
+	}
+
+	type pair struct {
+		snapIndex, orphanIndex int
+		similarity             float64
+	}
+	var pairs []pair
+	for i, snap := range snapshots {
+		if snap.IsInline() || !snap.IsNew() || snap.HasAccepted() {
+			continue
+		}
+		for j, orphan := range orphanSnaps {
+			if similarity := Similarity(orphan.Content, snap.Content); similarity >= MoveSimilarityThreshold {
+				pairs = append(pairs, pair{i, j, similarity})
+			}
+		}
+	}
+
+	// The most similar pairs are matched first.
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].similarity > pairs[j].similarity })
+
+	candidates := make(map[int]MoveCandidate)
+	pairedOrphans := make(map[int]bool)
+	for _, p := range pairs {
+		if _, ok := candidates[p.snapIndex]; ok || pairedOrphans[p.orphanIndex] {
+			continue
+		}
+		candidates[p.snapIndex] = MoveCandidate{From: orphanSnaps[p.orphanIndex], Similarity: p.similarity}
+		pairedOrphans[p.orphanIndex] = true
+	}
+	return candidates
+}
+
+// Returns how similar `a` and `b` are, from 0 (nothing in common) to 1 (identical), based on the edit
+// distance between them.
+func Similarity(a, b string) float64 {
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+
+	var distance int
+	for _, edit := range gotextdiff.Strings(a, b) {
+		distance += edit.End - edit.Start + len(edit.New)
+	}
+	return 1 - float64(distance)/float64(len(a)+len(b))
+}
+
+// Moves the orphaned snapshot `from` to the path of the new snapshot `s`, updating its header with the
+// name and source of `s`, then deletes `from`. The content of `from` is kept: if it differs from the
+// content of `s`, the new snapshot is left pending, to be reviewed against the moved one.
+func (s Snapshot) Move(from Snapshot) error {
+	moved := s
+	moved.Content = from.Content
+	if err := write(strings.TrimSuffix(s.path, ".new"), moved.Content, moved.header()); err != nil {
+		return err
+	}
+
+	if err := os.Remove(from.path); err != nil {
+		return err
+	}
+
+	if from.Content == s.Content {
+		return os.Remove(s.path)
+	}
+	return nil
+}
+
+// Returns the diff between the orphaned snapshot `from` and the new snapshot `s`.
+func (s Snapshot) DiffFrom(from Snapshot) string {
+	return diffContents(s.Serializer, from.Content, s.Content)
+}
@@ -0,0 +1,193 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Directories skipped by every scan, along with the hidden ones.
+var defaultIgnoredDirs = []string{"vendor", "node_modules"}
+
+// Returned along with the files found when some directories couldn't be read. The scan carries on
+// past them.
+type ScanError struct {
+	Errors []error
+}
+
+func (e *ScanError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d directories couldn't be read:\n  %s", len(e.Errors), strings.Join(msgs, "\n  "))
+}
+
+// Walks a directory tree looking for files, with `GOMAXPROCS` workers reading the queued directories.
+type scanner struct {
+	root     string
+	ignore   []string
+	suffixes []string
+
+	mu sync.Mutex
+	// Signaled when directories are queued, or when the last one was read.
+	cond  *sync.Cond
+	queue []scanDir
+	// Number of directories queued or being read.
+	pending int
+	paths   []string
+	errs    []error
+}
+
+// A directory to read, with the `.gitignore` rules of its parent directories.
+type scanDir struct {
+	path  string
+	rules []ignoreRule
+}
+
+// Returns the files below `root` whose name ends with one of `suffixes`, sorted. Hidden directories,
+// `vendor` and `node_modules`, the directories ignored by `.gitignore` files and those matching one of
+// the `ignore` names or glob patterns are skipped. Unreadable directories don't stop the scan: they are
+// reported with a `*ScanError`, returned along with the files found.
+func findFiles(root string, ignore []string, suffixes ...string) ([]string, error) {
+	s := &scanner{
+		root:     root,
+		ignore:   ignore,
+		suffixes: suffixes,
+		queue:    []scanDir{{path: root, rules: parentGitignoreRules(root)}},
+		pending:  1,
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work()
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(s.paths)
+	if len(s.errs) > 0 {
+		return s.paths, &ScanError{Errors: s.errs}
+	}
+	return s.paths, nil
+}
+
+// Returns the rules of the `.gitignore` files above `root`, up to the root of its git repository.
+func parentGitignoreRules(root string) []ignoreRule {
+	var rules []ignoreRule
+	for dir := root; dir != filepath.Dir(dir); {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return rules
+		}
+		dir = filepath.Dir(dir)
+		dirRules, _ := readGitignore(dir)
+		rules = append(dirRules, rules...)
+	}
+	// Not in a git repository.
+	return nil
+}
+
+// Reads the queued directories, queueing their sub-directories, until the whole tree was read.
+func (s *scanner) work() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for len(s.queue) == 0 && s.pending > 0 {
+			s.cond.Wait()
+		}
+		if s.pending == 0 {
+			return
+		}
+		// Reading the last queued directory first walks the tree depth first, which keeps the queue short.
+		dir := s.queue[len(s.queue)-1]
+		s.queue = s.queue[:len(s.queue)-1]
+
+		s.mu.Unlock()
+		subdirs, paths, errs := s.read(dir)
+		s.mu.Lock()
+
+		s.queue = append(s.queue, subdirs...)
+		s.paths = append(s.paths, paths...)
+		s.errs = append(s.errs, errs...)
+		s.pending += len(subdirs) - 1
+		if len(subdirs) > 0 || s.pending == 0 {
+			s.cond.Broadcast()
+		}
+	}
+}
+
+// Reads `dir`, returning the sub-directories to read next and the files found.
+func (s *scanner) read(dir scanDir) ([]scanDir, []string, []error) {
+	entries, err := os.ReadDir(dir.path)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+
+	var errs []error
+	rules := dir.rules
+	dirRules, err := readGitignore(dir.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	if len(dirRules) > 0 {
+		// The rules of the parent directories are shared by the sub-directories of its siblings.
+		rules = append(append([]ignoreRule(nil), rules...), dirRules...)
+	}
+
+	var subdirs []scanDir
+	var paths []string
+	for _, entry := range entries {
+		path := filepath.Join(dir.path, entry.Name())
+		if entry.IsDir() {
+			if !s.skipDir(path, rules) {
+				subdirs = append(subdirs, scanDir{path: path, rules: rules})
+			}
+			continue
+		}
+
+		for _, suffix := range s.suffixes {
+			if strings.HasSuffix(path, suffix) {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	return subdirs, paths, errs
+}
+
+func (s *scanner) skipDir(path string, rules []ignoreRule) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, ignored := range defaultIgnoredDirs {
+		if name == ignored {
+			return true
+		}
+	}
+	return isIgnored(s.root, path, s.ignore) || gitignoredDir(rules, path)
+}
+
+func isIgnored(root, path string, ignore []string) bool {
+	relPath, _ := filepath.Rel(root, path)
+	for _, pattern := range ignore {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGetNewSnapshotPathsReportsUnreadableDirs(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read every directory")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/pkg__TestA.snap.new":      "",
+		"locked/pkg__TestB.snap.new": "",
+		"z/pkg__TestC.snap.new":      "",
+	})
+	locked := filepath.Join(root, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	paths, err := GetNewSnapshotPaths([]string{root}, nil)
+
+	// The scan carries on past the unreadable directory.
+	want := []string{filepath.Join(root, "a/pkg__TestA.snap.new"), filepath.Join(root, "z/pkg__TestC.snap.new")}
+	if !slices.Equal(paths, want) {
+		t.Errorf("GetNewSnapshotPaths() = %v, want %v", paths, want)
+	}
+
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("GetNewSnapshotPaths() error = %v, want a *ScanError", err)
+	}
+	if len(scanErr.Errors) != 1 || !errors.Is(scanErr.Errors[0], os.ErrPermission) ||
+		!strings.Contains(scanErr.Error(), locked) {
+		t.Errorf("GetNewSnapshotPaths() error = %v, want a permission error on %s", err, locked)
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                  "generated/\n",
+		"generated/pkg__TestA.snap":   "",
+		".hidden/pkg__TestB.snap":     "",
+		"vendor/pkg__TestC.snap":      "",
+		"skipped/pkg__TestD.snap":     "",
+		"a/pkg__TestE.snap":           "",
+		"a/pkg__TestE.snap.new":       "",
+		"a/notes.txt":                 "",
+		"a/b/c/d/e/pkg__TestF.snap":   "",
+		"a/b/.gitignore":              "c/\n",
+		"a/b/pkg__TestG.snap":         "",
+		"z/generated/pkg__TestH.snap": "",
+	}
+	var want []string
+	for i := 0; i < 50; i++ {
+		path := fmt.Sprintf("wide/%02d/pkg__Test.snap", i)
+		files[path] = ""
+		want = append(want, filepath.Join(root, path))
+	}
+	writeFiles(t, root, files)
+
+	paths, err := findFiles(root, []string{"skipped"}, snapshotExt)
+	if err != nil {
+		t.Fatalf("findFiles() error = %v", err)
+	}
+	want = append([]string{
+		filepath.Join(root, "a/b/pkg__TestG.snap"),
+		filepath.Join(root, "a/pkg__TestE.snap"),
+	}, want...)
+	if !slices.Equal(paths, want) {
+		t.Errorf("findFiles() = %v, want %v", paths, want)
+	}
+}
@@ -1,16 +1,29 @@
 package snapshot
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/LaBatata101/goinsta/internal/config"
 	"github.com/LaBatata101/goinsta/internal/gotextdiff"
+	"github.com/LaBatata101/goinsta/internal/redaction"
+	"github.com/LaBatata101/goinsta/internal/serialize"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	snapshotExt      = ".snap"
+	newSnapshotExt   = ".snap.new"
+	pendingInlineExt = ".pending-snap"
 )
 
 type Snapshot struct {
@@ -19,39 +32,120 @@ type Snapshot struct {
 	Name    string
 	Source  string
 	Content string
+	// Name of the serializer that produced `Content`, e.g. `debug` or `json`.
+	Serializer string
+	// Optional description given by the test.
+	Description string
 }
 
 func (s Snapshot) Accept() error {
+	if s.IsInline() {
+		return acceptInline(s)
+	}
 	if s.IsNew() {
 		return os.Rename(s.path, strings.TrimSuffix(s.path, ".new"))
 	}
 	return nil
 }
 
-func (s Snapshot) Reject() {
+func (s Snapshot) Reject() error {
 	if s.IsNew() {
-		os.Remove(s.path)
+		return os.Remove(s.path)
 	}
+	return nil
+}
+
+// Number of unchanged lines shown around each change of a diff.
+var DiffContextLines = gotextdiff.DefaultContextLines
+
+// Returns the unified diff between the `old` and `new` contents.
+func unifiedDiff(old, new string) string {
+	diff, err := gotextdiff.ToUnified(old, gotextdiff.Strings(old, new), DiffContextLines)
+	if err != nil {
+		// Can't happen: edits are consistent.
+		log.Fatalf("internal error in unifiedDiff: %v", err)
+	}
+	return diff
+}
+
+// Returns the diff between the `old` and `new` contents of a snapshot stored with `serializer`. JSON, YAML
+// and TOML contents are normalized first, with sorted keys and the indentation of their serializer, so a
+// snapshot edited by hand only shows the values that changed. Contents that don't parse, and changes of
+// formatting alone, are diffed as they are.
+func diffContents(serializer, old, new string) string {
+	normalizedOld, normalizedNew := normalizeContent(serializer, old), normalizeContent(serializer, new)
+	if normalizedOld == normalizedNew {
+		return unifiedDiff(old, new)
+	}
+	return unifiedDiff(normalizedOld, normalizedNew)
+}
+
+// Parses `content` in the format of `serializer` and serializes it again, or returns it as is for the
+// other formats and when it doesn't parse.
+func normalizeContent(serializer, content string) string {
+	if content == "" {
+		return content
+	}
+
+	var (
+		tree   any
+		err    error
+		format func(any, redaction.Redactions) (string, error)
+	)
+	switch serializer {
+	case "json":
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		err, format = decoder.Decode(&tree), serialize.JSON
+	case "yaml":
+		err, format = yaml.Unmarshal([]byte(content), &tree), serialize.YAML
+	case "toml":
+		var table map[string]any
+		_, err = toml.Decode(content, &table)
+		tree, format = table, serialize.TOML
+	default:
+		return content
+	}
+	if err != nil {
+		return content
+	}
+
+	normalized, err := format(tree, nil)
+	if err != nil {
+		return content
+	}
+	return normalized
 }
 
 // Compute the difference between the new snapshot (.snap.new) and the old snapshot (.snap).
 // Return the diff string.
 func (s Snapshot) Diff() string {
+	if s.IsInline() {
+		// A missing literal is shown as an addition of the whole content.
+		old, _ := ReadInlineLiteral(s.Source, s.inlineLoc())
+		return diffContents(s.Serializer, old, s.Content)
+	}
+
 	oldSnapshotPath := strings.TrimSuffix(s.path, ".new")
 	_, err := os.Stat(oldSnapshotPath)
 	if s.IsNew() {
 		if err == nil {
 			// Don't need to handle error here, since, we already checkd that `oldSnapshotPath` exist.
 			oldSnap, _ := Read(oldSnapshotPath)
-			return gotextdiff.Unified(oldSnap.Content, s.Content)
+			return diffContents(s.Serializer, oldSnap.Content, s.Content)
 		} else if errors.Is(err, fs.ErrNotExist) {
-			return gotextdiff.Unified("", s.Content)
+			return unifiedDiff("", s.Content)
 		}
 	}
 	return ""
 }
 
 func (s Snapshot) HasDifference() bool {
+	if s.IsInline() {
+		old, err := ReadInlineLiteral(s.Source, s.inlineLoc())
+		return err == nil && old != "" && len(gotextdiff.Strings(old, s.Content)) > 0
+	}
+
 	oldSnapshotPath := strings.TrimSuffix(s.path, ".new")
 	_, err := os.Stat(oldSnapshotPath)
 	if s.IsNew() && err == nil {
@@ -63,101 +157,226 @@ func (s Snapshot) HasDifference() bool {
 	return false
 }
 
+// Reports whether the snapshot is still pending review, i.e. it is a `.snap.new` or `.pending-snap` file.
 func (s Snapshot) IsNew() bool {
-	return strings.HasSuffix(s.path, ".snap.new")
+	return strings.HasSuffix(s.path, newSnapshotExt) || s.IsInline()
 }
 
-// Return the snapshot path relative to the `go.mod` directory.
+// Reports whether the snapshot is a pending inline snapshot, whose accepted value lives as a string
+// literal in the test source file.
+func (s Snapshot) IsInline() bool {
+	return strings.HasSuffix(s.path, pendingInlineExt)
+}
+
+// Root of the workspace the snapshots belong to, if known.
+var WorkspaceRoot string
+
+// Return the snapshot path relative to the workspace root, or else to the `go.mod` directory.
 func (s Snapshot) CleanPath() string {
-	goModPath, found := findGoModPath(filepath.Dir(s.path))
-	if found {
-		return filepath.Join(filepath.Base(goModPath), strings.TrimPrefix(s.path, goModPath))
-	}
-	return s.path
+	return cleanPath(s.path)
 }
 
-func findGoModPath(dir string) (string, bool) {
-	currentDir := dir
-	for {
-		modPath := filepath.Join(currentDir, "go.mod")
-		_, err := os.Stat(modPath)
-		if err == nil {
-			return currentDir, true
-		}
+// Returns the path of the test source relative to the workspace root, or else to the `go.mod` directory.
+func (s Snapshot) CleanSource() string {
+	return cleanPath(s.Source)
+}
 
-		parentDir := filepath.Dir(currentDir)
-		if parentDir == currentDir {
-			break
-		}
-		currentDir = parentDir
+func cleanPath(path string) string {
+	if WorkspaceRoot != "" && strings.HasPrefix(path, WorkspaceRoot+string(filepath.Separator)) {
+		return filepath.Join(filepath.Base(WorkspaceRoot), strings.TrimPrefix(path, WorkspaceRoot))
 	}
-	return "", false
-}
 
-// Returns all the `.snap.new` snapshots in the current directory and its sub-directories.
-func GetNewSnapshotPaths() ([]string, error) {
-	var snapshots []string
-	currentDir, err := os.Getwd()
+	goModPath, found := config.FindModuleRoot(filepath.Dir(path))
+	if found {
+		return filepath.Join(filepath.Base(goModPath), strings.TrimPrefix(path, goModPath))
+	}
+	return path
+}
 
-	err = filepath.WalkDir(currentDir, func(path string, d fs.DirEntry, err error) error {
+// Returns the pending snapshots (`.snap.new` and `.pending-snap`) at `paths`: pending snapshot files are
+// returned as is, and directories are searched with their sub-directories, see `findFiles`. Unreadable
+// directories are reported with a `*ScanError`, returned along with the snapshots found.
+func GetNewSnapshotPaths(paths []string, ignore []string) ([]string, error) {
+	var snapPaths []string
+	var scanErr ScanError
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
 		if err != nil {
-			log.Printf("Error accessing path %q\n", path)
-			return err
+			return nil, err
 		}
 
-		if strings.HasSuffix(path, ".snap.new") {
-			snapshots = append(snapshots, path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !strings.HasSuffix(path, newSnapshotExt) && !strings.HasSuffix(path, pendingInlineExt) {
+				return nil, fmt.Errorf("%s is not a pending snapshot", path)
+			}
+			if !seen[path] {
+				seen[path] = true
+				snapPaths = append(snapPaths, path)
+			}
+			continue
 		}
 
-		return nil
-	})
-
-	return snapshots, err
+		found, err := findFiles(path, ignore, newSnapshotExt, pendingInlineExt)
+		if errs, ok := err.(*ScanError); ok {
+			scanErr.Errors = append(scanErr.Errors, errs.Errors...)
+		} else if err != nil {
+			return nil, err
+		}
+		for _, path := range found {
+			if !seen[path] {
+				seen[path] = true
+				snapPaths = append(snapPaths, path)
+			}
+		}
+	}
+	if len(scanErr.Errors) > 0 {
+		return snapPaths, &scanErr
+	}
+	return snapPaths, nil
 }
 
 // Parses a snapshot file into the `Snapshot` struct.
 // Returns an error if `snapshotPath` doesn't exist.
 func Read(snapshotPath string) (Snapshot, error) {
 	bytes, err := os.ReadFile(snapshotPath)
-	content := strings.Split(string(bytes), "---")
-	header := content[1]
-	snapContent := strings.Trim(content[2], "\n")
+	if err != nil {
+		return Snapshot{}, err
+	}
 
-	header = strings.Trim(header, "\n")
-	headerLines := strings.Split(header, "\n")
+	header, snapContent, err := parse(string(bytes))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", snapshotPath, err)
+	}
 
-	source := strings.TrimSpace(strings.Split(headerLines[0], ":")[1])
-	assertionLine := strings.TrimSpace(strings.Split(headerLines[1], ":")[1])
-	loc, err := strconv.ParseInt(assertionLine, 10, 0)
+	loc, err := strconv.Atoi(header["assertion_line"])
 	if err != nil {
-		panic("Failed to convert string to int")
+		return Snapshot{}, fmt.Errorf("%s: invalid assertion_line: %w", snapshotPath, err)
 	}
 
-	var name string
-	if strings.HasSuffix(snapshotPath, ".new") {
-		name = strings.TrimSuffix(filepath.Base(snapshotPath), filepath.Ext(snapshotPath))
+	name, ok := header["name"]
+	if !ok {
+		name = strings.TrimSuffix(filepath.Base(snapshotPath), ".new")
 		name = strings.TrimSuffix(name, filepath.Ext(name))
 		name = strings.ReplaceAll(name, "__", ".")
-	} else {
-		name = strings.ReplaceAll(strings.TrimSuffix(filepath.Base(snapshotPath), filepath.Ext(snapshotPath)), "__", ".")
 	}
 
-	return Snapshot{Source: source, Loc: int(loc), Content: snapContent, Name: name, path: snapshotPath}, err
+	return Snapshot{
+		Source:      header["source"],
+		Loc:         loc,
+		Content:     snapContent,
+		Name:        name,
+		Serializer:  header["serializer"],
+		Description: header["description"],
+		path:        snapshotPath,
+	}, nil
 }
 
-// Writes a `.snap.new` snapshot to `path`.
-func Write(path, snapshotName, content, source string, loc int) (Snapshot, error) {
-	path = path + ".new"
+// Returns the snapshot files in `dirs` (`.snap`, `.snap.new` and `.pending-snap`) of the snapshot named
+// `name`, like `pkg.TestFoo`. The accepted snapshot is left out when there is a pending one replacing it.
+func FindByName(dirs, ignore []string, name string) ([]string, error) {
+	fileName := strings.ReplaceAll(name, ".", "__") + snapshotExt
+	var scanErr ScanError
+	var found []string
+	for _, dir := range dirs {
+		paths, err := findFiles(dir, ignore, snapshotExt, newSnapshotExt, pendingInlineExt)
+		if errs, ok := err.(*ScanError); ok {
+			scanErr.Errors = append(scanErr.Errors, errs.Errors...)
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			if strings.HasSuffix(path, pendingInlineExt) {
+				// The names of inline snapshots are only recorded in their header.
+				if snap, err := Read(path); err == nil && snap.Name == name {
+					found = append(found, path)
+				}
+			} else if strings.TrimSuffix(filepath.Base(path), ".new") == fileName {
+				found = append(found, path)
+			}
+		}
+	}
+
+	var snapPaths []string
+	for _, path := range found {
+		if !slices.Contains(found, path+".new") {
+			snapPaths = append(snapPaths, path)
+		}
+	}
+	if len(scanErr.Errors) > 0 {
+		return snapPaths, &scanErr
+	}
+	return snapPaths, nil
+}
+
+// Splits the raw contents of a snapshot file into its header fields and its content.
+func parse(raw string) (map[string]string, string, error) {
+	raw = strings.TrimPrefix(raw, "---\n")
+	headerText, content, found := strings.Cut(raw, "\n---\n")
+	if !found {
+		return nil, "", errors.New("malformed snapshot header")
+	}
+
+	header := make(map[string]string)
+	for _, line := range strings.Split(headerText, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		header[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return header, strings.Trim(content, "\n"), nil
+}
+
+// Writes `snap` as a `.snap.new` snapshot to `path`.
+func Write(path string, snap Snapshot) (Snapshot, error) {
+	snap.path = path + ".new"
+	err := write(snap.path, snap.Content, snap.header())
+	return snap, err
+}
+
+// Returns the header fields of the snapshot file, in the order they are written.
+func (s Snapshot) header() [][2]string {
+	header := [][2]string{
+		{"source", s.Source},
+		{"assertion_line", strconv.Itoa(s.Loc)},
+	}
+	if s.IsInline() {
+		header = append(header, [2]string{"name", s.Name})
+	}
+	if s.Serializer != "" {
+		header = append(header, [2]string{"serializer", s.Serializer})
+	}
+	if s.Description != "" {
+		// The header is line based, so the description must fit in a single line.
+		header = append(header, [2]string{"description", strings.Join(strings.Fields(s.Description), " ")})
+	}
+	return header
+}
+
+func write(path, content string, header [][2]string) error {
 	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
-	_, err = fmt.Fprintln(file, "---")
-	_, err = fmt.Fprintf(file, "source: %s\n", source)
-	_, err = fmt.Fprintf(file, "assertion_line: %d\n", loc)
-	_, err = fmt.Fprintln(file, "---")
-	_, err = fmt.Fprint(file, content)
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, field := range header {
+		fmt.Fprintf(&b, "%s: %s\n", field[0], field[1])
+	}
+	b.WriteString("---\n")
+	b.WriteString(content)
 
-	return Snapshot{Source: source, Loc: int(loc), Content: content, Name: snapshotName, path: path}, err
+	_, err = file.WriteString(b.String())
+	return err
 }
 
 func RejectAll(paths []string) ([]Snapshot, error) {
@@ -168,7 +387,9 @@ func RejectAll(paths []string) ([]Snapshot, error) {
 			return rejectedSnaps, err
 		}
 
-		snap.Reject()
+		if err := snap.Reject(); err != nil {
+			return rejectedSnaps, err
+		}
 		rejectedSnaps = append(rejectedSnaps, snap)
 	}
 
@@ -183,7 +404,9 @@ func AcceptAll(paths []string) ([]Snapshot, error) {
 			return acceptSnaps, err
 		}
 
-		snap.Accept()
+		if err := snap.Accept(); err != nil {
+			return acceptSnaps, err
+		}
 		acceptSnaps = append(acceptSnaps, snap)
 	}
 
@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffContents(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer string
+		old        string
+		new        string
+		// Lines the diff must contain, and lines it must not.
+		want    []string
+		notWant []string
+	}{
+		{
+			name:       "json keys reordered",
+			serializer: "json",
+			old:        "{\"b\": 2, \"a\": 1}",
+			new:        "{\n  \"a\": 1,\n  \"b\": 3\n}",
+			want:       []string{"-  \"b\": 2", "+  \"b\": 3"},
+			notWant:    []string{"-{\"b\": 2, \"a\": 1}"},
+		},
+		{
+			name:       "yaml reindented",
+			serializer: "yaml",
+			old:        "items:\n    - one\n    - two\nname: x",
+			new:        "items:\n  - one\n  - three\nname: x",
+			want:       []string{"-  - two", "+  - three"},
+			notWant:    []string{"-    - one"},
+		},
+		{
+			name:       "toml tables",
+			serializer: "toml",
+			old:        "name = \"x\"\n\n[server]\n  port = 80",
+			new:        "name = \"x\"\n\n[server]\nport = 8080",
+			want:       []string{"-port = 80", "+port = 8080"},
+		},
+		{
+			name:       "formatting only",
+			serializer: "json",
+			old:        "{\"a\": 1}",
+			new:        "{\n  \"a\": 1\n}",
+			want:       []string{"-{\"a\": 1}", "+  \"a\": 1"},
+		},
+		{
+			name:       "invalid json",
+			serializer: "json",
+			old:        "{\"a\": 1",
+			new:        "{\"a\": 2",
+			want:       []string{"-{\"a\": 1", "+{\"a\": 2"},
+		},
+		{
+			name:       "other serializer",
+			serializer: "debug",
+			old:        "{\"b\": 2, \"a\": 1}",
+			new:        "{\"a\": 1, \"b\": 2}",
+			want:       []string{"-{\"b\": 2, \"a\": 1}", "+{\"a\": 1, \"b\": 2}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffContents(tt.serializer, tt.old, tt.new)
+			lines := strings.Split(diff, "\n")
+			for _, want := range tt.want {
+				if !slices.Contains(lines, want) {
+					t.Errorf("diff doesn't contain %q:\n%s", want, diff)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if slices.Contains(lines, notWant) {
+					t.Errorf("diff contains %q:\n%s", notWant, diff)
+				}
+			}
+		})
+	}
+}
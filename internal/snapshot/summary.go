@@ -4,6 +4,13 @@ type Summary struct {
 	Accepted []Snapshot
 	Rejected []Snapshot
 	Skipped  []Snapshot
+	Moved    []Move
+}
+
+// A snapshot moved to the name of a renamed test.
+type Move struct {
+	From Snapshot
+	To   Snapshot
 }
 
 func (s *Summary) AddAccepted(snapshot Snapshot) {
@@ -17,3 +24,7 @@ func (s *Summary) AddRejected(snapshot Snapshot) {
 func (s *Summary) AddSkipped(snapshot Snapshot) {
 	s.Skipped = append(s.Skipped, snapshot)
 }
+
+func (s *Summary) AddMoved(from, to Snapshot) {
+	s.Moved = append(s.Moved, Move{From: from, To: to})
+}
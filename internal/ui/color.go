@@ -1,6 +1,11 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
 
 var BoldText = lipgloss.NewStyle().Bold(true)
 var GreenText = lipgloss.NewStyle().Foreground(lipgloss.Color("#728F66"))
@@ -10,3 +15,46 @@ var RedText = lipgloss.NewStyle().Foreground(lipgloss.Color("#BF3F42"))
 var lineNumberColor = lipgloss.NewStyle().Foreground(lipgloss.Color("#6A9588"))
 var YellowText = lipgloss.NewStyle().Foreground(lipgloss.Color("#BA9E6B"))
 var grayText = lipgloss.NewStyle().Foreground(lipgloss.Color("#94907E"))
+
+// Colors of a theme, in the order: green, green2, red, line number, yellow, gray.
+var themes = map[string][6]lipgloss.Color{
+	"default":       {"#728F66", "#658E84", "#BF3F42", "#6A9588", "#BA9E6B", "#94907E"},
+	"high-contrast": {"10", "14", "9", "14", "11", "7"},
+}
+
+// When colors are used: `auto`, `always` or `never`.
+var colorMode = "auto"
+
+// Applies the color mode (`auto`, `always` or `never`) and the color theme (`default` or
+// `high-contrast`). Empty values keep the current setting.
+func Configure(color, theme string) error {
+	switch color {
+	case "":
+	case "auto":
+		colorMode = color
+	case "always":
+		colorMode = color
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	case "never":
+		colorMode = color
+		lipgloss.SetColorProfile(termenv.Ascii)
+	default:
+		return fmt.Errorf("invalid color %q, expected one of: auto, always, never", color)
+	}
+
+	if theme == "" {
+		return nil
+	}
+	colors, ok := themes[theme]
+	if !ok {
+		return fmt.Errorf("invalid theme %q, expected one of: default, high-contrast", theme)
+	}
+	GreenText = lipgloss.NewStyle().Foreground(colors[0])
+	greenText2 = lipgloss.NewStyle().Foreground(colors[1])
+	GreenText2Underlined = lipgloss.NewStyle().Underline(true).Inherit(greenText2)
+	RedText = lipgloss.NewStyle().Foreground(colors[2])
+	lineNumberColor = lipgloss.NewStyle().Foreground(colors[3])
+	YellowText = lipgloss.NewStyle().Foreground(colors[4])
+	grayText = lipgloss.NewStyle().Foreground(colors[5])
+	return nil
+}
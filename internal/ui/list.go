@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/LaBatata101/goinsta/internal/snapshot"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/truncate"
+)
+
+// Header of the snapshots of a test source file in the snapshot list.
+type groupItem struct {
+	pkg    string
+	source string
+}
+
+// Headers never match a filter, the filtered snapshots show their package instead.
+func (g groupItem) FilterValue() string {
+	return ""
+}
+
+// A snapshot of the snapshot list.
+type snapshotItem struct {
+	// Index of the snapshot in the review.
+	index int
+	pkg   string
+	info  snapshot.Info
+}
+
+func (i snapshotItem) FilterValue() string {
+	return i.info.Name
+}
+
+// Renders the items of the snapshot list on a single line, with the decision taken on the snapshots.
+type snapshotDelegate struct {
+	decided map[int]decision
+}
+
+func (d snapshotDelegate) Height() int {
+	return 1
+}
+
+func (d snapshotDelegate) Spacing() int {
+	return 0
+}
+
+func (d snapshotDelegate) Update(tea.Msg, *list.Model) tea.Cmd {
+	return nil
+}
+
+func (d snapshotDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	var line string
+	switch item := item.(type) {
+	case groupItem:
+		line = BoldText.Render(item.pkg) + " " + grayText.Render(item.source)
+	case snapshotItem:
+		cursor := "  "
+		if index == m.Index() {
+			cursor = YellowText.Bold(true).Render("> ")
+		}
+
+		marker := " "
+		if decision, ok := d.decided[item.index]; ok {
+			marker = decisionMarker(decision.action)
+		}
+
+		// The group headers are hidden while filtering.
+		name := item.info.Name
+		if !m.IsFiltered() {
+			name = strings.TrimPrefix(name, item.pkg+".")
+		}
+
+		status := GreenText.Render("new")
+		if item.info.Status == "changed" {
+			status = YellowText.Render("changed")
+		}
+
+		line = fmt.Sprintf("%s%s %s %s %s %s", cursor, marker, name, status,
+			GreenText.Render(fmt.Sprintf("+%d", item.info.Added)), RedText.Render(fmt.Sprintf("-%d", item.info.Removed)))
+	}
+	fmt.Fprint(w, truncate.StringWithTail(line, uint(m.Width()), "…"))
+}
+
+func decisionMarker(action snapshot.Action) string {
+	switch action {
+	case snapshot.ActionAccept:
+		return GreenText.Render("✓")
+	case snapshot.ActionReject:
+		return RedText.Render("✗")
+	case snapshot.ActionMove:
+		return greenText2.Render("→")
+	default:
+		return YellowText.Render("-")
+	}
+}
+
+// Returns the list of the snapshots, grouped by package and test source file.
+func newSnapshotList(snapshots []snapshot.Snapshot, decided map[int]decision) list.Model {
+	indexes := make([]int, len(snapshots))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return snapshots[indexes[i]].Source < snapshots[indexes[j]].Source
+	})
+
+	var items []list.Item
+	for i, index := range indexes {
+		snap := snapshots[index]
+		pkg, _, _ := strings.Cut(snap.Name, ".")
+		if i == 0 || snap.Source != snapshots[indexes[i-1]].Source {
+			items = append(items, groupItem{pkg: pkg, source: snap.CleanSource()})
+		}
+		items = append(items, snapshotItem{index: index, pkg: pkg, info: snap.Info()})
+	}
+
+	l := list.New(items, snapshotDelegate{decided: decided}, 0, 0)
+	l.Title = "Snapshots"
+	l.Styles.Title = BoldText
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.DisableQuitKeybindings()
+	return l
+}
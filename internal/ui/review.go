@@ -1,10 +1,15 @@
 package ui
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/LaBatata101/goinsta/internal/snapshot"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/paginator"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/bubbletea"
@@ -18,12 +23,43 @@ type reviewModel struct {
 	viewport        viewport.Model
 	isViewportReady bool
 	drawScrollBar   bool
-	summary         *snapshot.Summary
-	windowHeight    int
-	windowWidth     int
+	// Orphaned snapshots that the new snapshots probably replace, keyed by snapshot index.
+	moves map[int]snapshot.MoveCandidate
+	// Decisions taken, in order. Nothing is changed on disk until they are confirmed.
+	history []decision
+	// Last decision taken on each snapshot that is no longer pending, keyed by snapshot index.
+	decided map[int]decision
+	// Showing the decisions to confirm before applying them.
+	confirming bool
+	// The decisions were confirmed and should be applied.
+	confirmed bool
+	// List of the snapshots, shown next to the diff.
+	list        list.Model
+	showList    bool
+	listFocused bool
+	// Showing the diffs in two columns instead of a unified diff.
+	sideBySide   bool
+	windowHeight int
+	windowWidth  int
 }
 
-func ReviewSnapshotsModel(snapPaths []string, summary *snapshot.Summary) reviewModel {
+// A decision taken on a snapshot during the review.
+type decision struct {
+	index  int
+	action snapshot.Action
+}
+
+// Decision keys of the review.
+var keyActions = map[string]snapshot.Action{
+	"a": snapshot.ActionAccept,
+	"r": snapshot.ActionReject,
+	"s": snapshot.ActionSkip,
+	"m": snapshot.ActionMove,
+}
+
+// Returns the model reviewing the snapshots at `snapPaths`. New snapshots whose content matches one of
+// the orphaned snapshots in `orphans` can be moved over it, instead of accepting one and deleting the other.
+func ReviewSnapshotsModel(snapPaths, orphans []string) reviewModel {
 	var snapshots []snapshot.Snapshot
 	for _, snapshotPath := range snapPaths {
 		// Don't need to handle error here, since, we have valid snap paths at this point.
@@ -39,12 +75,33 @@ func ReviewSnapshotsModel(snapPaths []string, summary *snapshot.Summary) reviewM
 	p.KeyMap.PrevPage = key.NewBinding(key.WithDisabled())
 	p.SetTotalPages(len(snapshots))
 
-	return reviewModel{
+	decided := make(map[int]decision)
+	m := reviewModel{
 		snapshots:     snapshots,
 		currSnapIndex: 0,
 		paginator:     p,
-		summary:       summary,
+		moves:         snapshot.FindMoveCandidates(snapshots, orphans),
+		decided:       decided,
+		list:          newSnapshotList(snapshots, decided),
 	}
+	m.selectInList(0)
+	return m
+}
+
+// Runs the interactive review of the snapshots at `snapPaths`, then applies the decisions if they were
+// confirmed and adds them to `summary`. The decisions are applied all at once: if one fails, the
+// snapshots are left as they were.
+func ReviewSnapshots(snapPaths, orphans []string, summary *snapshot.Summary) error {
+	p := tea.NewProgram(ReviewSnapshotsModel(snapPaths, orphans), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	model, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	if m := model.(reviewModel); m.confirmed {
+		return snapshot.Apply(m.decisions(), summary)
+	}
+	return nil
 }
 
 const useHighPerformanceRenderer = false
@@ -66,29 +123,44 @@ func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "a":
-			m.summary.AddAccepted(m.snapshots[m.currSnapIndex])
-			m.snapshots[m.currSnapIndex].Accept()
-			m.currSnapIndex++
-			if m.currSnapIndex < len(m.snapshots) {
-				m.paginator.NextPage()
+		switch {
+		case msg.String() == "ctrl+c":
+			return m, tea.Quit
+		case m.listFocused && !m.confirming:
+			if cmd := m.updateList(msg); cmd != nil {
+				return m, cmd
 			}
-		case "r":
-			m.summary.AddRejected(m.snapshots[m.currSnapIndex])
-			m.snapshots[m.currSnapIndex].Reject()
-			m.currSnapIndex++
-			if m.currSnapIndex < len(m.snapshots) {
-				m.paginator.NextPage()
+		case m.confirming:
+			switch msg.String() {
+			case "y", "enter":
+				m.confirmed = true
+				return m, tea.Quit
+			case "b", "esc":
+				m.confirming = false
+			case "u":
+				m.undo()
+			case "q":
+				return m, tea.Quit
 			}
-		case "s":
-			m.summary.AddSkipped(m.snapshots[m.currSnapIndex])
-			m.currSnapIndex++
-			if m.currSnapIndex < len(m.snapshots) {
-				m.paginator.NextPage()
+		default:
+			switch msg.String() {
+			case "a", "r", "m", "s":
+				m.decide(keyActions[msg.String()])
+			case "u":
+				m.undo()
+			case "p":
+				m.goTo(max(0, m.currSnapIndex-1))
+			case "n":
+				m.goTo(min(len(m.snapshots)-1, m.currSnapIndex+1))
+			case "tab":
+				m.showList, m.listFocused = true, true
+			case "v":
+				m.sideBySide = !m.sideBySide
+			case "q", "esc":
+				if cmd := m.stop(); cmd != nil {
+					return m, cmd
+				}
 			}
-		case "q", "esc", "ctrl+c":
-			return m, tea.Quit
 		}
 	case tea.WindowSizeMsg:
 		m.windowHeight, m.windowWidth = msg.Height, msg.Width
@@ -117,6 +189,7 @@ func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - verticalMarginHeight
 		}
+		m.list.SetSize(listWidth(msg.Width)-1, msg.Height)
 
 		if useHighPerformanceRenderer {
 			// Render (or re-render) the whole viewport. Necessary both to
@@ -127,25 +200,31 @@ func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	if m.currSnapIndex >= len(m.snapshots) {
-		return m, tea.Quit
+	// Messages of the list, like its filter results, are handled whether it has the focus or not.
+	if _, ok := msg.(tea.KeyMsg); !ok {
+		m.list, cmd = m.list.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
-	snap := m.currSnapshot()
-	contentHeight := lipgloss.Height(snap.Diff())
-	if contentHeight > m.viewport.Height {
-		if !m.drawScrollBar {
-			m.drawScrollBar = true
+	content := m.content(m.viewport.Width)
+	if m.isViewportReady {
+		// The header and footer depend on the snapshot being reviewed, the width on the list being shown.
+		m.viewport.Height = m.windowHeight - lipgloss.Height(m.headerView()) - lipgloss.Height(m.footerView())
+		m.viewport.Width = m.contentWidth()
+		content = m.content(m.viewport.Width)
+		m.drawScrollBar = lipgloss.Height(content) > m.viewport.Height
+		if m.drawScrollBar {
 			m.viewport.Width -= lipgloss.Width(scrollBarBlock)
+			content = m.content(m.viewport.Width)
 		}
-	} else {
-		m.drawScrollBar = false
 	}
-	m.viewport.SetContent(diffView(m.viewport.Width, snap))
+	m.viewport.SetContent(content)
 
-	// Handle keyboard and mouse events in the viewport
-	m.viewport, cmd = m.viewport.Update(msg)
-	cmds = append(cmds, cmd)
+	// Handle keyboard and mouse events in the viewport, unless the list has the focus
+	if _, ok := msg.(tea.KeyMsg); !ok || !m.listFocused || m.confirming {
+		m.viewport, cmd = m.viewport.Update(msg)
+		cmds = append(cmds, cmd)
+	}
 
 	m.paginator, cmd = m.paginator.Update(msg)
 	cmds = append(cmds, cmd)
@@ -153,12 +232,172 @@ func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-func (m reviewModel) View() string {
-	var b strings.Builder
+// Records the decision `action` on the current snapshot, then moves on to the next pending snapshot, or to
+// the confirmation once every snapshot is decided. Snapshots already decided must be undone first.
+func (m *reviewModel) decide(action snapshot.Action) {
+	if m.record(m.currSnapIndex, action) {
+		m.nextPending()
+	}
+}
+
+// Records the decision `action` on the snapshots of the list, filtered or not, that are still pending.
+func (m *reviewModel) decideAll(action snapshot.Action) {
+	for _, item := range m.list.VisibleItems() {
+		if item, ok := item.(snapshotItem); ok {
+			m.record(item.index, action)
+		}
+	}
+	if _, ok := m.decided[m.currSnapIndex]; ok {
+		m.nextPending()
+	}
+}
+
+// Records the decision `action` on the snapshot at `index`. Returns true if the snapshot is decided, false
+// if it is still pending: after a move to a different content, or if the decision doesn't apply to it.
+func (m *reviewModel) record(index int, action snapshot.Action) bool {
+	if _, ok := m.decided[index]; ok {
+		return false
+	}
+	snap := m.snapshots[index]
+	candidate, canMove := m.moves[index]
+	if action == snapshot.ActionMove && (!canMove || m.isMoved(index)) {
+		return false
+	}
+
+	d := decision{index: index, action: action}
+	m.history = append(m.history, d)
+	// When the contents differ the new snapshot is still pending, to review as a change of the moved one.
+	if action == snapshot.ActionMove && candidate.From.Content != snap.Content {
+		return false
+	}
+
+	m.decided[index] = d
+	return true
+}
+
+// Undoes the last decision and goes back to its snapshot.
+func (m *reviewModel) undo() {
+	if len(m.history) == 0 {
+		return
+	}
+	d := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+
+	delete(m.decided, d.index)
+	m.confirming = false
+	m.goTo(d.index)
+}
+
+// Reports whether the orphaned snapshot of the snapshot at `index` is to be moved over it.
+func (m reviewModel) isMoved(index int) bool {
+	for _, d := range m.history {
+		if d.index == index && d.action == snapshot.ActionMove {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the decisions taken, in order, to apply them.
+func (m reviewModel) decisions() []snapshot.Decision {
+	decisions := make([]snapshot.Decision, len(m.history))
+	for i, d := range m.history {
+		decisions[i] = snapshot.Decision{Snapshot: m.snapshots[d.index], Action: d.action}
+		if d.action == snapshot.ActionMove {
+			decisions[i].From = m.moves[d.index].From
+		}
+	}
+	return decisions
+}
+
+// Stops the review: quits if no decision was taken, or else shows the decisions to confirm them.
+func (m *reviewModel) stop() tea.Cmd {
+	if len(m.history) == 0 {
+		return tea.Quit
+	}
+	m.confirming = true
+	m.viewport.GotoTop()
+	return nil
+}
+
+// Handles the keys of the snapshot list while it has the focus.
+func (m *reviewModel) updateList(msg tea.KeyMsg) tea.Cmd {
+	if !m.list.SettingFilter() {
+		switch msg.String() {
+		case "tab":
+			m.showList, m.listFocused = false, false
+			return nil
+		case "enter":
+			if item, ok := m.list.SelectedItem().(snapshotItem); ok {
+				m.goTo(item.index)
+				m.listFocused = false
+			}
+			return nil
+		case "A":
+			m.decideAll(snapshot.ActionAccept)
+			return nil
+		case "R":
+			m.decideAll(snapshot.ActionReject)
+			return nil
+		case "q":
+			return m.stop()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return cmd
+}
+
+// Moves the cursor of the list to the snapshot at `index`, unless the list is filtered.
+func (m *reviewModel) selectInList(index int) {
+	if m.list.IsFiltered() {
+		return
+	}
+	for i, item := range m.list.Items() {
+		if item, ok := item.(snapshotItem); ok && item.index == index {
+			m.list.Select(i)
+			return
+		}
+	}
+}
 
-	if m.currSnapIndex >= len(m.snapshots) {
-		return ""
+// Returns the width of the snapshot list in a terminal `width` columns wide.
+func listWidth(width int) int {
+	return min(max(width/3, 30), 60, width/2)
+}
+
+// Returns the width left for the diff, next to the snapshot list if it is shown.
+func (m reviewModel) contentWidth() int {
+	if !m.showList || m.confirming {
+		return m.windowWidth
+	}
+	return m.windowWidth - listWidth(m.windowWidth)
+}
+
+// Moves on to the next snapshot still pending, wrapping around to the ones left behind, or to the
+// confirmation when every snapshot was decided.
+func (m *reviewModel) nextPending() {
+	for i := 1; i <= len(m.snapshots); i++ {
+		index := (m.currSnapIndex + i) % len(m.snapshots)
+		if _, ok := m.decided[index]; !ok {
+			m.goTo(index)
+			return
+		}
 	}
+	m.confirming = true
+	m.viewport.GotoTop()
+}
+
+func (m *reviewModel) goTo(index int) {
+	m.currSnapIndex = index
+	m.paginator.Page = index
+	m.viewport.GotoTop()
+	m.selectInList(index)
+}
+
+func (m reviewModel) View() string {
+	var b strings.Builder
 
 	scrollBar := ""
 	if m.drawScrollBar {
@@ -171,6 +410,11 @@ func (m reviewModel) View() string {
 		lipgloss.JoinHorizontal(0, m.viewport.View(), scrollBar)))
 	b.WriteString(m.footerView())
 
+	if m.showList && !m.confirming {
+		listView := lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderRight(true).
+			Width(listWidth(m.windowWidth) - 1).Height(m.windowHeight).Render(m.list.View())
+		return lipgloss.JoinHorizontal(lipgloss.Top, listView, b.String())
+	}
 	return b.String()
 }
 
@@ -182,19 +426,183 @@ func (m reviewModel) scrollBar() string {
 	return strings.Repeat("\n", max(0, pos-lipgloss.Height(scrollBarBlock))) + scrollBarBlock
 }
 
+// Returns the content of the viewport, `width` columns wide: the decisions to confirm, or else the diff of
+// the current snapshot in the chosen layout.
+func (m reviewModel) content(width int) string {
+	switch {
+	case m.confirming:
+		return m.confirmView()
+	case m.sideBySide:
+		return sideBySideDiffView(width, m.diff())
+	default:
+		return unifiedDiffView(width, m.diff())
+	}
+}
+
+// Returns the diff of the current snapshot, against its move candidate if it has one.
+func (m reviewModel) diff() string {
+	if candidate, ok := m.moves[m.currSnapIndex]; ok {
+		return m.currSnapshot().DiffFrom(candidate.From)
+	}
+	return m.currSnapshot().Diff()
+}
+
 func (m reviewModel) headerView() string {
-	return lipgloss.JoinVertical(0, summaryHeader(m.windowWidth, m.currSnapshot()),
-		diffHeader(m.windowWidth, m.currSnapshot()))
+	if m.confirming {
+		return confirmHeader(m.contentWidth())
+	}
+
+	header := summaryHeader(m.contentWidth(), m.currSnapshot())
+	if d, ok := m.decided[m.currSnapIndex]; ok {
+		header = lipgloss.JoinVertical(0, header, "Decision: "+actionView(d.action))
+	}
+
+	candidate, ok := m.moves[m.currSnapIndex]
+	if !ok {
+		return lipgloss.JoinVertical(0, header, diffHeader(m.contentWidth(), m.currSnapshot()))
+	}
+
+	label, old := "Renamed from", "-orphaned snapshot"
+	if m.isMoved(m.currSnapIndex) {
+		label, old = "Moved from", "-moved snapshot"
+	}
+	moveHeader := fmt.Sprintf("%s: %s %s", label, GreenText2Underlined.Render(candidate.From.CleanPath()),
+		grayText.Render(fmt.Sprintf("(%.0f%% similar)", candidate.Similarity*100)))
+	return lipgloss.JoinVertical(0, header, moveHeader, RedText.Render(old), GreenText.Render("+new results"),
+		strings.Repeat("─", m.contentWidth()))
+}
+
+func confirmHeader(termWidth int) string {
+	headerText := BoldText.Render(" Confirm Review ")
+	headerLine := strings.Repeat("━", max(0, termWidth-lipgloss.Width(headerText))/2)
+	return lipgloss.JoinVertical(0, headerLine+headerText+headerLine,
+		"The snapshots are left untouched until the decisions below are applied.", strings.Repeat("─", termWidth))
+}
+
+// Lists the decisions to apply, grouped by action.
+func (m reviewModel) confirmView() string {
+	var lines []string
+	for _, action := range []snapshot.Action{snapshot.ActionAccept, snapshot.ActionReject, snapshot.ActionSkip, snapshot.ActionMove} {
+		var entries []string
+		for _, d := range m.decisions() {
+			if d.Action != action {
+				continue
+			}
+			entry := fmt.Sprintf("  %s (%s)", d.Snapshot.CleanPath(), YellowText.Render(d.Snapshot.Name))
+			if action == snapshot.ActionMove {
+				entry = fmt.Sprintf("  %s -> %s", d.From.CleanPath(), d.Snapshot.CleanPath())
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) > 0 {
+			lines = append(lines, fmt.Sprintf("%s (%d):", actionView(action), len(entries)))
+			lines = append(append(lines, entries...), "")
+		}
+	}
+
+	if pending := len(m.snapshots) - len(m.decided); pending > 0 {
+		lines = append(lines, grayText.Render(fmt.Sprintf("%d snapshots left pending", pending)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func actionView(action snapshot.Action) string {
+	switch action {
+	case snapshot.ActionAccept:
+		return GreenText.Render("Accept")
+	case snapshot.ActionReject:
+		return RedText.Render("Reject")
+	case snapshot.ActionMove:
+		return greenText2.Render("Move")
+	default:
+		return YellowText.Render("Skip")
+	}
 }
 
 func (m reviewModel) footerView() string {
 	var b strings.Builder
-	b.WriteString("\n" + strings.Repeat("─", m.windowWidth))
+	b.WriteString("\n" + strings.Repeat("─", m.contentWidth()))
+	if m.confirming {
+		b.WriteString("\n\n")
+		b.WriteString("  " + GreenText.Render("y") + " apply  " + grayText.Render("apply the decisions") + "\n")
+		b.WriteString("  " + YellowText.Render("b") + " back   " + grayText.Render("go back to the review") + "\n")
+		b.WriteString("  " + YellowText.Render("u") + " undo   " + grayText.Render("undo the last decision") + "\n")
+		b.WriteString("  " + RedText.Bold(true).Render("q quit   ") + grayText.Render("quit without applying") + "\n")
+		return b.String()
+	}
 	b.WriteString("\n" + m.paginator.View())
 	b.WriteString("\n\n")
-	b.WriteString("  " + GreenText.Render("a") + " accept " + grayText.Render("keep the new snapshot") + "\n")
-	b.WriteString("  " + RedText.Render("r") + " reject " + grayText.Render("reject the new snapshot") + "\n")
-	b.WriteString("  " + YellowText.Render("s") + " skip   " + grayText.Render("keep both for now") + "\n")
-	b.WriteString("  " + RedText.Bold(true).Render("q quit   ") + grayText.Render("stop reviewing") + "\n")
+	if _, ok := m.decided[m.currSnapIndex]; !ok && !m.listFocused {
+		b.WriteString("  " + GreenText.Render("a") + " accept " + grayText.Render("keep the new snapshot") + "\n")
+		b.WriteString("  " + RedText.Render("r") + " reject " + grayText.Render("reject the new snapshot") + "\n")
+		b.WriteString("  " + YellowText.Render("s") + " skip   " + grayText.Render("keep both for now") + "\n")
+		if _, ok := m.moves[m.currSnapIndex]; ok && !m.isMoved(m.currSnapIndex) {
+			b.WriteString("  " + greenText2.Render("m") + " move   " + grayText.Render("move the orphaned snapshot to the new name") + "\n")
+		}
+	}
+	if len(m.history) > 0 && !m.listFocused {
+		b.WriteString("  " + YellowText.Render("u") + " undo   " + grayText.Render("undo the last decision") + "\n")
+	}
+	if m.listFocused {
+		b.WriteString("  " + grayText.Render("/") + "        " + grayText.Render("filter the snapshots") + "\n")
+		b.WriteString("  " + grayText.Render("enter") + "    " + grayText.Render("go to the snapshot") + "\n")
+		b.WriteString("  " + GreenText.Render("A") + "/" + RedText.Render("R") + "      " + grayText.Render("accept/reject every snapshot listed") + "\n")
+		b.WriteString("  " + grayText.Render("tab") + "      " + grayText.Render("hide the list") + "\n")
+	} else {
+		b.WriteString("  " + grayText.Render("p/n") + "      " + grayText.Render("previous/next snapshot") + "\n")
+		b.WriteString("  " + grayText.Render("v") + "        " + grayText.Render("side-by-side/unified diff") + "\n")
+		b.WriteString("  " + grayText.Render("tab") + "      " + grayText.Render("snapshot list") + "\n")
+	}
+	b.WriteString("  " + RedText.Bold(true).Render("q quit   ") + grayText.Render("stop reviewing and confirm the decisions") + "\n")
 	return b.String()
 }
+
+// Reviews the snapshots at `snapPaths` without a terminal, like `ReviewSnapshots` does: prints the summary
+// and diff of each snapshot to `out`, `width` columns wide, and reads the decisions from `in`, one per
+// line. The review stops on `q` or at the end of `in`, then the decisions are applied all at once and added
+// to `summary`.
+func ReviewSnapshotsNonInteractive(snapPaths, orphans []string, summary *snapshot.Summary, in io.Reader, out io.Writer, width int) error {
+	m := ReviewSnapshotsModel(snapPaths, orphans)
+	m.windowWidth = width
+
+	scanner := bufio.NewScanner(in)
+review:
+	for !m.confirming {
+		fmt.Fprintln(out, lipgloss.JoinVertical(0, m.headerView(), unifiedDiffView(width, m.diff())))
+
+		choices := "accept (a), reject (r), skip (s)"
+		if len(m.history) > 0 {
+			choices += ", undo (u)"
+		}
+		_, canMove := m.moves[m.currSnapIndex]
+		canMove = canMove && !m.isMoved(m.currSnapIndex)
+		if canMove {
+			choices += ", move (m)"
+		}
+		for {
+			fmt.Fprintf(out, "[%d/%d] %s or quit (q)? ", m.currSnapIndex+1, len(m.snapshots), choices)
+			if !scanner.Scan() {
+				fmt.Fprintln(out)
+				break review
+			}
+
+			answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			key, _ := utf8.DecodeRuneInString(answer)
+			if key == 'q' {
+				break review
+			}
+			if key == 'u' && len(m.history) > 0 {
+				m.undo()
+				break
+			}
+			if key == 'a' || key == 'r' || key == 's' || key == 'm' && canMove {
+				// A snapshot still pending after a move is shown again, with its diff against the moved one.
+				m.decide(keyActions[string(key)])
+				break
+			}
+		}
+		fmt.Fprintln(out)
+	}
+
+	return snapshot.Apply(m.decisions(), summary)
+}
@@ -29,6 +29,10 @@ func PrintSummary(summary *snapshot.Summary) {
 	if len(summary.Skipped) > 0 {
 		PrintSkipped(summary.Skipped)
 	}
+
+	if len(summary.Moved) > 0 {
+		PrintMoved(summary.Moved)
+	}
 }
 
 func PrintAccepted(snaps []snapshot.Snapshot) {
@@ -52,8 +56,25 @@ func PrintSkipped(snaps []snapshot.Snapshot) {
 	}
 }
 
+func PrintMoved(moves []snapshot.Move) {
+	fmt.Println(greenText2.Render("Moved") + ":")
+	for _, move := range moves {
+		fmt.Printf("  %s (%s -> %s)\n", move.To.Source, move.From.Name, move.To.Name)
+	}
+}
+
+func PrintPaths(title string, paths []string) {
+	fmt.Println(title + ":")
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+}
+
 func RenderSnapshotSummary(snap *snapshot.Snapshot) {
-	lipgloss.SetColorProfile(termenv.TrueColor)
+	// The output of `go test` is usually not a terminal, force the colors unless they were disabled.
+	if colorMode != "never" {
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
 
 	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
@@ -66,6 +87,12 @@ func RenderSnapshotSummary(snap *snapshot.Snapshot) {
 
 func SnapshotSummary(snap *snapshot.Snapshot, termWidth int) string {
 	header := summaryHeader(termWidth, snap)
+	if !snap.IsNew() {
+		// Accepted snapshots have nothing to compare with, show their content instead.
+		content := " " + strings.ReplaceAll(strings.TrimSuffix(snap.Content, "\n"), "\n", "\n ")
+		return lipgloss.JoinVertical(0, header, unifiedDiffView(termWidth, content), strings.Repeat("─", termWidth))
+	}
+
 	diff := lipgloss.JoinVertical(0.05, diffHeader(termWidth, snap), diffView(termWidth, snap))
 	return lipgloss.JoinVertical(0, header, diff, strings.Repeat("─", termWidth))
 }
@@ -84,8 +111,16 @@ func summaryHeader(termWidth int, snap *snapshot.Snapshot) string {
 	s2 := fmt.Sprintf("Snapshot: %s", YellowText.Render(snap.Name))
 	s3 := fmt.Sprintf("Source: %s:%s", greenText2.Render(snap.Source),
 		lipgloss.NewStyle().Bold(true).Render(strconv.Itoa(snap.Loc)))
+	lines := []string{header, s1, s2, s3}
+	if snap.Serializer != "" {
+		lines = append(lines, fmt.Sprintf("Format: %s", YellowText.Render(snap.Serializer)))
+	}
+	if snap.Description != "" {
+		lines = append(lines, fmt.Sprintf("Description: %s", snap.Description))
+	}
+	lines = append(lines, strings.Repeat("─", termWidth))
 
-	return lipgloss.JoinVertical(0, header, s1, s2, s3, strings.Repeat("─", termWidth))
+	return lipgloss.JoinVertical(0, lines...)
 }
 
 func diffHeader(termWidth int, snap *snapshot.Snapshot) string {
@@ -98,10 +133,14 @@ func diffHeader(termWidth int, snap *snapshot.Snapshot) string {
 }
 
 func diffView(termWidth int, snap *snapshot.Snapshot) string {
+	return unifiedDiffView(termWidth, snap.Diff())
+}
+
+func unifiedDiffView(termWidth int, diff string) string {
 	var loc int
 	var coloredLines []string
 	var lineNumbersColumn []string
-	scanner := bufio.NewScanner(strings.NewReader(snap.Diff()))
+	scanner := bufio.NewScanner(strings.NewReader(diff))
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
@@ -134,3 +173,64 @@ func diffView(termWidth int, snap *snapshot.Snapshot) string {
 
 	return lipgloss.JoinHorizontal(0, lineNumbersText, sourceBorder.Render(diffText))
 }
+
+// Renders `diff` in two columns, the old lines on the left and the new ones on the right, `termWidth`
+// columns wide. Removed lines are paired with the lines added in their place, so that both columns stay
+// aligned; long lines wrap within their column.
+func sideBySideDiffView(termWidth int, diff string) string {
+	var rows [][2]string
+	var oldLines, newLines []string
+	flush := func() {
+		for i := range max(len(oldLines), len(newLines)) {
+			var row [2]string
+			if i < len(oldLines) {
+				row[0] = oldLines[i]
+			}
+			if i < len(newLines) {
+				row[1] = newLines[i]
+			}
+			rows = append(rows, row)
+		}
+		oldLines, newLines = nil, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "-"):
+			// A removal after additions starts a new change.
+			if len(newLines) > 0 {
+				flush()
+			}
+			oldLines = append(oldLines, line)
+		case strings.HasPrefix(line, "+"):
+			newLines = append(newLines, line)
+		default:
+			flush()
+			rows = append(rows, [2]string{line, line})
+		}
+	}
+	flush()
+
+	numberWidth := len(strconv.Itoa(len(rows)))
+	// The line numbers column, then the two columns with a border on their left.
+	columnWidth := max(1, (termWidth-numberWidth-1-2)/2)
+	column := lipgloss.NewStyle().Width(columnWidth).BorderStyle(lipgloss.NormalBorder()).BorderLeft(true)
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		oldLine, newLine := row[0], row[1]
+		if strings.HasPrefix(oldLine, "-") {
+			oldLine = RedText.Render(oldLine)
+		}
+		if strings.HasPrefix(newLine, "+") {
+			newLine = GreenText.Render(newLine)
+		}
+		number := lineNumberColor.Render(fmt.Sprintf("%*d", numberWidth, i+1)) + " "
+		lines[i] = lipgloss.JoinHorizontal(lipgloss.Top, number, column.Render(oldLine), column.Render(newLine))
+	}
+	return strings.Join(lines, "\n")
+}